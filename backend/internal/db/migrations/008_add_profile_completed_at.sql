@@ -0,0 +1,5 @@
+ALTER TABLE users ADD COLUMN IF NOT EXISTS profile_completed_at TIMESTAMP;
+
+-- profiles that were already complete before the column existed are
+-- backfilled by utils.BackfillProfileCompletedAt after the migrations run,
+-- so the required fields stay defined only in utils.RequiredProfileFields
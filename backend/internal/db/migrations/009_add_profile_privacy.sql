@@ -0,0 +1,11 @@
+-- per-field visibility: 'everyone' (anyone allowed to view the profile),
+-- 'connections' (accepted connections only) or 'nobody'
+ALTER TABLE users
+ADD COLUMN IF NOT EXISTS surname_visibility VARCHAR(12) NOT NULL DEFAULT 'connections'
+  CHECK (surname_visibility IN ('everyone','connections','nobody')),
+ADD COLUMN IF NOT EXISTS birthdate_visibility VARCHAR(12) NOT NULL DEFAULT 'connections'
+  CHECK (birthdate_visibility IN ('everyone','connections','nobody')),
+ADD COLUMN IF NOT EXISTS city_visibility VARCHAR(12) NOT NULL DEFAULT 'everyone'
+  CHECK (city_visibility IN ('everyone','connections','nobody')),
+ADD COLUMN IF NOT EXISTS photo_visibility VARCHAR(12) NOT NULL DEFAULT 'everyone'
+  CHECK (photo_visibility IN ('everyone','connections','nobody'));
@@ -0,0 +1,9 @@
+-- 'active': shown in recommendations
+-- 'paused': hidden from everyone until paused_until (or indefinitely when NULL)
+-- 'incognito': only shown to people the user has sent a connection request to
+ALTER TABLE users
+ADD COLUMN IF NOT EXISTS visibility VARCHAR(10) NOT NULL DEFAULT 'active'
+  CHECK (visibility IN ('active','paused','incognito')),
+ADD COLUMN IF NOT EXISTS paused_until TIMESTAMPTZ;
+
+CREATE INDEX IF NOT EXISTS idx_users_paused_until ON users (paused_until) WHERE visibility = 'paused';
@@ -0,0 +1,44 @@
+-- registry of optional profile attributes; adding a row here makes a new
+-- attribute available without schema or handler changes
+CREATE TABLE IF NOT EXISTS attribute_definitions (
+  id SERIAL PRIMARY KEY,
+  name VARCHAR(50) UNIQUE NOT NULL,
+  label VARCHAR(100) NOT NULL,
+  value_type VARCHAR(10) NOT NULL CHECK (value_type IN ('int','enum','multi_enum','bool')),
+  allowed_values JSONB,
+  min_value INT,
+  max_value INT,
+  filterable BOOLEAN NOT NULL DEFAULT TRUE,
+  dealbreaker BOOLEAN NOT NULL DEFAULT FALSE,
+  created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TABLE IF NOT EXISTS user_attributes (
+  user_id INT REFERENCES users(id) ON DELETE CASCADE,
+  attribute_id INT REFERENCES attribute_definitions(id) ON DELETE CASCADE,
+  value JSONB NOT NULL,
+  updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+  PRIMARY KEY (user_id, attribute_id)
+);
+
+-- what a user wants from a partner for a filterable attribute
+CREATE TABLE IF NOT EXISTS user_attribute_filters (
+  user_id INT REFERENCES users(id) ON DELETE CASCADE,
+  attribute_id INT REFERENCES attribute_definitions(id) ON DELETE CASCADE,
+  accepted_values JSONB,
+  min_value INT,
+  max_value INT,
+  dealbreaker BOOLEAN NOT NULL DEFAULT FALSE,
+  PRIMARY KEY (user_id, attribute_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_user_attributes_attribute ON user_attributes (attribute_id);
+
+INSERT INTO attribute_definitions (name, label, value_type, allowed_values, min_value, max_value, filterable, dealbreaker)
+VALUES
+  ('height', 'Height (cm)', 'int', NULL, 120, 230, TRUE, TRUE),
+  ('languages', 'Languages', 'multi_enum', '["English","Estonian","Russian","German","Spanish","French"]', NULL, NULL, TRUE, TRUE),
+  ('education', 'Education', 'enum', '["High school","Vocational","Bachelor","Master","Doctorate"]', NULL, NULL, TRUE, FALSE),
+  ('smoking', 'Smoking', 'enum', '["Never","Sometimes","Regularly"]', NULL, NULL, TRUE, TRUE),
+  ('kids', 'Kids', 'enum', '["Have kids","Want kids","Don''t want kids","Not sure"]', NULL, NULL, TRUE, TRUE)
+ON CONFLICT (name) DO NOTHING;
@@ -0,0 +1,20 @@
+-- recommendations are precomputed in batches; each recomputation for a user
+-- bumps their generation and only rows of the current generation are served
+ALTER TABLE recommendations ADD COLUMN IF NOT EXISTS generation INT NOT NULL DEFAULT 0;
+
+-- older code inserted a row on every request; keep only the newest per pair
+DELETE FROM recommendations a
+USING recommendations b
+WHERE a.user_id = b.user_id
+  AND a.recommended_user_id = b.recommended_user_id
+  AND a.id < b.id;
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_recommendations_pair ON recommendations (user_id, recommended_user_id);
+CREATE INDEX IF NOT EXISTS idx_recommendations_generation ON recommendations (user_id, generation, score DESC);
+
+CREATE TABLE IF NOT EXISTS recommendation_state (
+  user_id INT PRIMARY KEY REFERENCES users(id) ON DELETE CASCADE,
+  generation INT NOT NULL DEFAULT 0,
+  computed_at TIMESTAMPTZ,
+  stale BOOLEAN NOT NULL DEFAULT TRUE
+);
@@ -0,0 +1,2 @@
+-- when a recommendation was last shown in the feed
+ALTER TABLE recommendations ADD COLUMN IF NOT EXISTS served_at TIMESTAMPTZ;
@@ -0,0 +1,5 @@
+-- dismissals may expire; an expired dismissal only keeps hiding the user
+-- while their profile is unchanged since it was made (see 027)
+ALTER TABLE dismissed_recommendations ADD COLUMN IF NOT EXISTS expires_at TIMESTAMPTZ;
+
+CREATE INDEX IF NOT EXISTS idx_dismissed_recent ON dismissed_recommendations (user_id, created_at DESC);
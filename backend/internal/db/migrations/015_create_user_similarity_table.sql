@@ -0,0 +1,10 @@
+-- nearest neighbours by taste, rebuilt offline from likes and dismissals
+CREATE TABLE IF NOT EXISTS user_similarity (
+  user_id INT REFERENCES users(id) ON DELETE CASCADE,
+  similar_user_id INT REFERENCES users(id) ON DELETE CASCADE,
+  similarity DOUBLE PRECISION NOT NULL,
+  computed_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+  PRIMARY KEY (user_id, similar_user_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_user_similarity_similar ON user_similarity (similar_user_id);
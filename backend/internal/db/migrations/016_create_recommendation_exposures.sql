@@ -0,0 +1,9 @@
+-- which candidates were shown to which viewers each day, to cap daily exposure
+CREATE TABLE IF NOT EXISTS recommendation_exposures (
+  candidate_id INT REFERENCES users(id) ON DELETE CASCADE,
+  viewer_id INT REFERENCES users(id) ON DELETE CASCADE,
+  day DATE NOT NULL DEFAULT CURRENT_DATE,
+  PRIMARY KEY (candidate_id, day, viewer_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_recommendation_exposures_viewer ON recommendation_exposures (viewer_id, day);
@@ -0,0 +1,5 @@
+-- the experiment and variant whose strategy produced each recommendation
+ALTER TABLE recommendations ADD COLUMN IF NOT EXISTS experiment TEXT;
+ALTER TABLE recommendations ADD COLUMN IF NOT EXISTS variant TEXT;
+
+CREATE INDEX IF NOT EXISTS idx_recommendations_experiment ON recommendations (experiment, variant);
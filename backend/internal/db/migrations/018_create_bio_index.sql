@@ -0,0 +1,36 @@
+-- term counts of each user's "about" text, for TF-IDF bio similarity
+CREATE TABLE IF NOT EXISTS bio_documents (
+  user_id INT PRIMARY KEY REFERENCES users(id) ON DELETE CASCADE,
+  indexed_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE TABLE IF NOT EXISTS bio_terms (
+  user_id INT REFERENCES bio_documents(user_id) ON DELETE CASCADE,
+  term TEXT NOT NULL,
+  count INT NOT NULL,
+  PRIMARY KEY (user_id, term)
+);
+
+-- how many bios contain each term, kept in step with bio_terms by a trigger
+CREATE TABLE IF NOT EXISTS bio_term_stats (
+  term TEXT PRIMARY KEY,
+  document_count INT NOT NULL
+);
+
+CREATE OR REPLACE FUNCTION update_bio_term_stats() RETURNS TRIGGER AS $$
+BEGIN
+  IF TG_OP = 'INSERT' THEN
+    INSERT INTO bio_term_stats (term, document_count) VALUES (NEW.term, 1)
+    ON CONFLICT (term) DO UPDATE SET document_count = bio_term_stats.document_count + 1;
+    RETURN NEW;
+  END IF;
+  UPDATE bio_term_stats SET document_count = document_count - 1 WHERE term = OLD.term;
+  DELETE FROM bio_term_stats WHERE term = OLD.term AND document_count <= 0;
+  RETURN OLD;
+END;
+$$ LANGUAGE plpgsql;
+
+DROP TRIGGER IF EXISTS bio_terms_stats ON bio_terms;
+CREATE TRIGGER bio_terms_stats
+AFTER INSERT OR DELETE ON bio_terms
+FOR EACH ROW EXECUTE FUNCTION update_bio_term_stats();
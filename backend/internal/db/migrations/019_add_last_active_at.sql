@@ -0,0 +1,15 @@
+-- when the user was last seen: authenticated requests and open chat connections
+ALTER TABLE users ADD COLUMN IF NOT EXISTS last_active_at TIMESTAMPTZ;
+
+-- best available estimate for accounts from before tracking
+UPDATE users
+SET last_active_at = GREATEST(
+    created_at,
+    (SELECT MAX(created_at) FROM chats WHERE sender_id = users.id),
+    (SELECT MAX(created_at) FROM connections WHERE user_id = users.id)
+)
+WHERE last_active_at IS NULL;
+
+ALTER TABLE users ALTER COLUMN last_active_at SET DEFAULT NOW();
+
+CREATE INDEX IF NOT EXISTS idx_users_last_active_at ON users (last_active_at);
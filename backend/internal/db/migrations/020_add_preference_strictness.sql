@@ -0,0 +1,7 @@
+-- whether each partner preference is a dealbreaker ('hard') or only lowers the score ('soft')
+ALTER TABLE users ADD COLUMN IF NOT EXISTS gender_strictness VARCHAR(4) NOT NULL DEFAULT 'hard';
+ALTER TABLE users ADD COLUMN IF NOT EXISTS age_strictness VARCHAR(4) NOT NULL DEFAULT 'hard';
+ALTER TABLE users ADD COLUMN IF NOT EXISTS age_tolerance INT NOT NULL DEFAULT 0;
+ALTER TABLE users ADD COLUMN IF NOT EXISTS min_shared_hobbies INT NOT NULL DEFAULT 0;
+ALTER TABLE users ADD COLUMN IF NOT EXISTS min_shared_interests INT NOT NULL DEFAULT 0;
+ALTER TABLE users ADD COLUMN IF NOT EXISTS shared_strictness VARCHAR(4) NOT NULL DEFAULT 'soft';
@@ -0,0 +1,32 @@
+-- compatibility questionnaire: admins define multiple-choice questions, users
+-- answer them and say which answers they accept from a partner and how much
+-- that matters to them
+CREATE TABLE IF NOT EXISTS questions (
+  id SERIAL PRIMARY KEY,
+  text VARCHAR(300) UNIQUE NOT NULL,
+  options JSONB NOT NULL,
+  -- retired questions keep their answers but no longer count
+  active BOOLEAN NOT NULL DEFAULT TRUE,
+  created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TABLE IF NOT EXISTS user_answers (
+  user_id INT REFERENCES users(id) ON DELETE CASCADE,
+  question_id INT REFERENCES questions(id) ON DELETE CASCADE,
+  answer VARCHAR(100) NOT NULL,
+  acceptable JSONB NOT NULL,
+  importance VARCHAR(20) NOT NULL DEFAULT 'somewhat',
+  answered_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+  PRIMARY KEY (user_id, question_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_user_answers_question ON user_answers (question_id);
+
+INSERT INTO questions (text, options)
+VALUES
+  ('Do you want to have children someday?', '["Yes","No","Not sure"]'),
+  ('How often do you go out on weekends?', '["Almost every weekend","Sometimes","Rarely"]'),
+  ('Would you move to another city for a partner?', '["Yes","Maybe","No"]'),
+  ('How important is religion in your life?', '["Very important","Somewhat important","Not important"]'),
+  ('Do you want pets at home?', '["Yes","No","Don''t mind"]')
+ON CONFLICT (text) DO NOTHING;
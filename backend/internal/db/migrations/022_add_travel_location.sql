@@ -0,0 +1,8 @@
+-- time-boxed travel location: while a trip is on, recommendations use it
+-- instead of the home country and city, which stay untouched
+ALTER TABLE users ADD COLUMN IF NOT EXISTS travel_country VARCHAR(100);
+ALTER TABLE users ADD COLUMN IF NOT EXISTS travel_city VARCHAR(100);
+ALTER TABLE users ADD COLUMN IF NOT EXISTS travel_starts_on DATE;
+ALTER TABLE users ADD COLUMN IF NOT EXISTS travel_ends_on DATE;
+-- whether recommendations were already recomputed for the trip's start
+ALTER TABLE users ADD COLUMN IF NOT EXISTS travel_applied BOOLEAN NOT NULL DEFAULT FALSE;
@@ -0,0 +1,17 @@
+-- IANA time zone; daily like quotas reset at the user's local midnight
+ALTER TABLE users ADD COLUMN IF NOT EXISTS timezone VARCHAR(64) NOT NULL DEFAULT 'UTC';
+
+-- a super like puts the sender at the top of the recipient's lists
+ALTER TABLE connections ADD COLUMN IF NOT EXISTS super_like BOOLEAN NOT NULL DEFAULT FALSE;
+
+-- every like counts towards the daily quota, even if the request is later
+-- rejected or withdrawn
+CREATE TABLE IF NOT EXISTS sent_likes (
+  id SERIAL PRIMARY KEY,
+  user_id INT REFERENCES users(id) ON DELETE CASCADE,
+  target_id INT REFERENCES users(id) ON DELETE CASCADE,
+  super_like BOOLEAN NOT NULL DEFAULT FALSE,
+  sent_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_sent_likes_user_sent_at ON sent_likes (user_id, sent_at);
@@ -0,0 +1,13 @@
+-- a block hides both users from each other everywhere: recommendations,
+-- profiles, requests, chat, online status and notifications
+CREATE TABLE IF NOT EXISTS blocks (
+  blocker_id INT REFERENCES users(id) ON DELETE CASCADE,
+  blocked_id INT REFERENCES users(id) ON DELETE CASCADE,
+  created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+  PRIMARY KEY (blocker_id, blocked_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_blocks_blocked ON blocks (blocked_id);
+
+-- who a notification is about, so notifications from blocked users can be hidden
+ALTER TABLE notifications ADD COLUMN IF NOT EXISTS actor_id INT REFERENCES users(id) ON DELETE CASCADE;
@@ -0,0 +1,50 @@
+-- reports of abusive profiles or messages, worked through by moderators
+CREATE TABLE IF NOT EXISTS reports (
+  id SERIAL PRIMARY KEY,
+  reporter_id INT REFERENCES users(id) ON DELETE SET NULL,
+  target_id INT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+  chat_id INT REFERENCES chats(id) ON DELETE SET NULL,
+  -- copy of the reported message, kept when the message itself is deleted
+  message TEXT,
+  category VARCHAR(30) NOT NULL,
+  details TEXT,
+  -- open -> in_review (assigned) -> resolved or dismissed
+  status VARCHAR(20) NOT NULL DEFAULT 'open',
+  assigned_to VARCHAR(100),
+  -- what the moderator did: dismiss, warn, suspend, ban or delete_content
+  action VARCHAR(20),
+  resolution_note TEXT,
+  created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+  updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+  resolved_at TIMESTAMP
+);
+
+-- a reporter has at most one unresolved report per user, so counts of
+-- unresolved reports are counts of independent reporters
+CREATE UNIQUE INDEX IF NOT EXISTS idx_reports_unresolved
+  ON reports (reporter_id, target_id) WHERE status IN ('open', 'in_review');
+CREATE INDEX IF NOT EXISTS idx_reports_status ON reports (status, created_at);
+CREATE INDEX IF NOT EXISTS idx_reports_target ON reports (target_id);
+
+-- set when a user was reported by enough people; hidden users are not
+-- recommended until a moderator reviews them
+ALTER TABLE users ADD COLUMN IF NOT EXISTS hidden_at TIMESTAMP;
+
+-- moderation sanctions
+ALTER TABLE users ADD COLUMN IF NOT EXISTS suspended_until TIMESTAMPTZ;
+ALTER TABLE users ADD COLUMN IF NOT EXISTS suspension_reason TEXT;
+ALTER TABLE users ADD COLUMN IF NOT EXISTS banned_at TIMESTAMPTZ;
+ALTER TABLE users ADD COLUMN IF NOT EXISTS ban_reason TEXT;
+
+-- every action a moderator took against a user
+CREATE TABLE IF NOT EXISTS moderation_actions (
+  id SERIAL PRIMARY KEY,
+  user_id INT REFERENCES users(id) ON DELETE CASCADE,
+  report_id INT REFERENCES reports(id) ON DELETE SET NULL,
+  action VARCHAR(20) NOT NULL,
+  moderator VARCHAR(100),
+  note TEXT,
+  created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_moderation_actions_user ON moderation_actions (user_id, created_at);
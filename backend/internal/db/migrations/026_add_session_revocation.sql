@@ -0,0 +1,3 @@
+-- tokens issued before this time are rejected; set when a user is suspended
+-- or banned so they are logged out everywhere
+ALTER TABLE users ADD COLUMN IF NOT EXISTS sessions_valid_after TIMESTAMPTZ;
@@ -0,0 +1,40 @@
+-- what the dismissed user's profile looked like when they were dismissed;
+-- profile_changed is recomputed whenever they edit their profile and lets
+-- an expired dismissal resurface them once it changed substantially
+ALTER TABLE dismissed_recommendations ADD COLUMN IF NOT EXISTS snapshot_about TEXT;
+ALTER TABLE dismissed_recommendations ADD COLUMN IF NOT EXISTS snapshot_hobbies JSONB;
+ALTER TABLE dismissed_recommendations ADD COLUMN IF NOT EXISTS snapshot_interests JSONB;
+ALTER TABLE dismissed_recommendations ADD COLUMN IF NOT EXISTS snapshot_photo TEXT;
+ALTER TABLE dismissed_recommendations ADD COLUMN IF NOT EXISTS snapshot_city VARCHAR(100);
+ALTER TABLE dismissed_recommendations ADD COLUMN IF NOT EXISTS profile_changed BOOLEAN NOT NULL DEFAULT FALSE;
+
+CREATE INDEX IF NOT EXISTS idx_dismissed_user ON dismissed_recommendations (dismissed_user_id);
+
+-- replace the md5 fingerprints of older dismissals: unchanged profiles get
+-- their current state as snapshot, changed ones keep no snapshot and stay
+-- changed
+DO $$
+BEGIN
+  IF EXISTS (
+    SELECT 1 FROM information_schema.columns
+    WHERE table_name = 'dismissed_recommendations' AND column_name = 'profile_fingerprint'
+  ) THEN
+    UPDATE dismissed_recommendations d
+    SET profile_changed = TRUE
+    FROM users u
+    WHERE u.id = d.dismissed_user_id
+      AND d.profile_fingerprint IS DISTINCT FROM md5(concat_ws('|', u.about, u.hobbies::text, u.interests::text,
+                                                                u.profile_picture_url, u.city));
+
+    UPDATE dismissed_recommendations d
+    SET snapshot_about = u.about,
+        snapshot_hobbies = COALESCE(u.hobbies, '[]'::jsonb),
+        snapshot_interests = COALESCE(u.interests, '[]'::jsonb),
+        snapshot_photo = u.profile_picture_url,
+        snapshot_city = u.city
+    FROM users u
+    WHERE u.id = d.dismissed_user_id AND NOT d.profile_changed;
+
+    ALTER TABLE dismissed_recommendations DROP COLUMN profile_fingerprint;
+  END IF;
+END $$;
@@ -0,0 +1,15 @@
+-- every candidate a user was shown and when they first saw them; unlike
+-- recommendations.served_at this survives recomputation, so offline
+-- evaluation can replay what users actually saw
+CREATE TABLE IF NOT EXISTS served_recommendations (
+  user_id INT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+  recommended_user_id INT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+  served_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+  PRIMARY KEY (user_id, recommended_user_id)
+);
+
+INSERT INTO served_recommendations (user_id, recommended_user_id, served_at)
+SELECT user_id, recommended_user_id, served_at
+FROM recommendations
+WHERE served_at IS NOT NULL
+ON CONFLICT DO NOTHING;
@@ -0,0 +1,23 @@
+-- the same bounds the profile validation enforces: strictness is 'hard' or
+-- 'soft', age_tolerance at most 10 years and the minimum shared hobbies and
+-- interests at most the 8 items a profile can list
+-- the columns already exist, so each constraint is added only once
+DO $$
+DECLARE
+  c RECORD;
+BEGIN
+  FOR c IN SELECT * FROM (VALUES
+    ('users_gender_strictness_check', $c$gender_strictness IN ('hard','soft')$c$),
+    ('users_age_strictness_check', $c$age_strictness IN ('hard','soft')$c$),
+    ('users_shared_strictness_check', $c$shared_strictness IN ('hard','soft')$c$),
+    ('users_age_tolerance_check', $c$age_tolerance BETWEEN 0 AND 10$c$),
+    ('users_min_shared_hobbies_check', $c$min_shared_hobbies BETWEEN 0 AND 8$c$),
+    ('users_min_shared_interests_check', $c$min_shared_interests BETWEEN 0 AND 8$c$)
+  ) AS checks (name, expr)
+  LOOP
+    IF NOT EXISTS (SELECT 1 FROM pg_constraint WHERE conname = c.name) THEN
+      EXECUTE format('ALTER TABLE users ADD CONSTRAINT %I CHECK (%s)', c.name, c.expr);
+    END IF;
+  END LOOP;
+END
+$$;
@@ -0,0 +1,10 @@
+-- importance is one of scoring.ImportanceLevels; the column already exists,
+-- so the constraint is added only once
+DO $$
+BEGIN
+  IF NOT EXISTS (SELECT 1 FROM pg_constraint WHERE conname = 'user_answers_importance_check') THEN
+    ALTER TABLE user_answers ADD CONSTRAINT user_answers_importance_check
+      CHECK (importance IN ('irrelevant','a_little','somewhat','very','mandatory'));
+  END IF;
+END
+$$;
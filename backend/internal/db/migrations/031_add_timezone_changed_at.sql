@@ -0,0 +1,3 @@
+-- the time zone decides when like quotas reset, so how often it may change
+-- is limited; NULL until it is first changed
+ALTER TABLE users ADD COLUMN IF NOT EXISTS timezone_changed_at TIMESTAMPTZ;
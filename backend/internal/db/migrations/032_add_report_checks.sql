@@ -0,0 +1,14 @@
+-- report statuses and moderator actions as the moderation handlers know
+-- them; the columns already exist, so each constraint is added only once
+DO $$
+BEGIN
+  IF NOT EXISTS (SELECT 1 FROM pg_constraint WHERE conname = 'reports_status_check') THEN
+    ALTER TABLE reports ADD CONSTRAINT reports_status_check
+      CHECK (status IN ('open','in_review','resolved','dismissed'));
+  END IF;
+  IF NOT EXISTS (SELECT 1 FROM pg_constraint WHERE conname = 'reports_action_check') THEN
+    ALTER TABLE reports ADD CONSTRAINT reports_action_check
+      CHECK (action IN ('dismiss','warn','suspend','ban','delete_content'));
+  END IF;
+END
+$$;
@@ -0,0 +1,13 @@
+-- the experiment and variant whose strategy produced a recommendation when
+-- it was first served; experiment reports count from here because the
+-- recommendations rows are replaced on every recompute
+ALTER TABLE served_recommendations ADD COLUMN IF NOT EXISTS experiment TEXT;
+ALTER TABLE served_recommendations ADD COLUMN IF NOT EXISTS variant TEXT;
+
+CREATE INDEX IF NOT EXISTS idx_served_recommendations_experiment ON served_recommendations (experiment, variant);
+
+UPDATE served_recommendations s
+SET experiment = r.experiment, variant = r.variant
+FROM recommendations r
+WHERE r.user_id = s.user_id AND r.recommended_user_id = s.recommended_user_id
+  AND s.experiment IS NULL AND r.experiment IS NOT NULL;
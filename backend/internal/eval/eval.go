@@ -0,0 +1,223 @@
+// Package eval replays historical likes and dismissals against a scoring
+// strategy to measure recommendation quality offline.
+package eval
+
+import (
+	"math"
+	"sort"
+	"strings"
+	"time"
+
+	"matchme-backend/internal/models"
+	"matchme-backend/internal/scoring"
+)
+
+// users and what they did with each other
+type Dataset struct {
+	Users []models.User
+	// Likes[a][b]: a sent b a connection request (after b was served to a,
+	// when Served is known)
+	Likes map[int]map[int]bool
+	// Dismissed[a][b]: a dismissed b (likewise after b was served)
+	Dismissed map[int]map[int]bool
+	// Matches[a][b] (and [b][a]): a and b are connected
+	Matches map[int]map[int]bool
+	// Served[a]: the candidates a was actually shown; when set, only viewers
+	// with a served list are evaluated and only on what they were shown,
+	// otherwise every viewer is ranked against everyone in scope
+	Served map[int][]int
+}
+
+// quality of a strategy's top-k lists, averaged over viewers with at least one like
+type Report struct {
+	Strategy string
+	K        int
+	Viewers  int
+	// share of the top k the viewer liked
+	Precision float64
+	// share of the viewer's likes found in the top k
+	Recall float64
+	// discounted gain of likes by rank, relative to the ideal ordering
+	NDCG float64
+	// share of all users that appear in somebody's top k
+	Coverage float64
+	// share of all recommended pairs that ended up connected
+	MutualMatchRate float64
+	// share of all recommended pairs the viewer dismissed
+	DismissalRate float64
+}
+
+// ranks the candidates of every viewer with the strategy and compares the
+// top k with what the viewer actually liked and dismissed
+// candidates are the ones the viewer was served or, without served lists,
+// all other users in the strategy's scope; either way they include the ones
+// the viewer already reacted to, since those reactions are the ground truth
+func Run(ds Dataset, strategy *scoring.Strategy, k int) Report {
+	report := Report{Strategy: strategy.Name, K: k}
+	recommended := make(map[int]bool)
+	var recommendedPairs, matchedPairs, dismissedPairs int
+
+	byID := make(map[int]models.User, len(ds.Users))
+	for _, u := range ds.Users {
+		byID[u.UserID] = u
+	}
+
+	now := time.Now()
+	for _, viewer := range ds.Users {
+		viewer.LastActiveAt = &now
+
+		candidates := ds.Users
+		if ds.Served != nil {
+			served, ok := ds.Served[viewer.UserID]
+			if !ok {
+				continue
+			}
+			candidates = candidates[:0:0]
+			for _, id := range served {
+				if u, ok := byID[id]; ok {
+					candidates = append(candidates, u)
+				}
+			}
+		}
+		liked := relevantLikes(ds.Likes[viewer.UserID], candidates)
+
+		top := rank(viewer, candidates, strategy, k)
+		for _, id := range top {
+			recommended[id] = true
+			recommendedPairs++
+			if ds.Matches[viewer.UserID][id] {
+				matchedPairs++
+			}
+			if ds.Dismissed[viewer.UserID][id] {
+				dismissedPairs++
+			}
+		}
+		if len(liked) == 0 {
+			continue
+		}
+
+		report.Viewers++
+		report.Precision += PrecisionAtK(top, liked, k)
+		report.Recall += RecallAtK(top, liked, k)
+		report.NDCG += NDCGAtK(top, liked, k)
+	}
+
+	if report.Viewers > 0 {
+		report.Precision /= float64(report.Viewers)
+		report.Recall /= float64(report.Viewers)
+		report.NDCG /= float64(report.Viewers)
+	}
+	if len(ds.Users) > 0 {
+		report.Coverage = float64(len(recommended)) / float64(len(ds.Users))
+	}
+	if recommendedPairs > 0 {
+		report.MutualMatchRate = float64(matchedPairs) / float64(recommendedPairs)
+		report.DismissalRate = float64(dismissedPairs) / float64(recommendedPairs)
+	}
+	return report
+}
+
+// the liked users among the candidates; likes of anyone the viewer could
+// not have been recommended cannot be found and do not count against recall
+func relevantLikes(liked map[int]bool, candidates []models.User) map[int]bool {
+	relevant := make(map[int]bool)
+	for _, u := range candidates {
+		if liked[u.UserID] {
+			relevant[u.UserID] = true
+		}
+	}
+	return relevant
+}
+
+// the IDs of the viewer's k best candidates, best first
+func rank(viewer models.User, users []models.User, strategy *scoring.Strategy, k int) []int {
+	type scored struct {
+		id    int
+		score float64
+	}
+	var candidates []scored
+	for _, u := range users {
+		if u.UserID == viewer.UserID || !inScope(viewer, u, strategy.CandidateScope) {
+			continue
+		}
+		s, skip := strategy.Score(viewer, u)
+		if skip || s <= 0 {
+			continue
+		}
+		candidates = append(candidates, scored{u.UserID, s})
+	}
+	sort.Slice(candidates, func(i, j int) bool {
+		if candidates[i].score != candidates[j].score {
+			return candidates[i].score > candidates[j].score
+		}
+		return candidates[i].id < candidates[j].id
+	})
+	if len(candidates) > k {
+		candidates = candidates[:k]
+	}
+	ids := make([]int, len(candidates))
+	for i, c := range candidates {
+		ids[i] = c.id
+	}
+	return ids
+}
+
+// mirrors the location part of the live candidate query
+func inScope(viewer, candidate models.User, scope string) bool {
+	if viewer.Country == nil || candidate.Country == nil || !strings.EqualFold(*viewer.Country, *candidate.Country) {
+		return false
+	}
+	if scope == scoring.ScopeCountry {
+		return true
+	}
+	return viewer.City != nil && candidate.City != nil && strings.EqualFold(*viewer.City, *candidate.City)
+}
+
+func hits(top []int, relevant map[int]bool, k int) int {
+	n := 0
+	for i, id := range top {
+		if i >= k {
+			break
+		}
+		if relevant[id] {
+			n++
+		}
+	}
+	return n
+}
+
+// relevant items in the first k, over k
+func PrecisionAtK(top []int, relevant map[int]bool, k int) float64 {
+	if k <= 0 {
+		return 0
+	}
+	return float64(hits(top, relevant, k)) / float64(k)
+}
+
+// relevant items in the first k, over all relevant items
+func RecallAtK(top []int, relevant map[int]bool, k int) float64 {
+	if len(relevant) == 0 {
+		return 0
+	}
+	return float64(hits(top, relevant, k)) / float64(len(relevant))
+}
+
+// normalised discounted cumulative gain of the first k with binary relevance
+func NDCGAtK(top []int, relevant map[int]bool, k int) float64 {
+	var dcg, ideal float64
+	for i, id := range top {
+		if i >= k {
+			break
+		}
+		if relevant[id] {
+			dcg += 1 / math.Log2(float64(i+2))
+		}
+	}
+	for i := 0; i < k && i < len(relevant); i++ {
+		ideal += 1 / math.Log2(float64(i+2))
+	}
+	if ideal == 0 {
+		return 0
+	}
+	return dcg / ideal
+}
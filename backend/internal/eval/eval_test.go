@@ -0,0 +1,108 @@
+package eval
+
+import (
+	"math"
+	"testing"
+
+	"matchme-backend/internal/scoring"
+)
+
+func TestRankingMetrics(t *testing.T) {
+	top := []int{1, 2, 3, 4}
+	relevant := map[int]bool{2: true, 4: true, 9: true}
+
+	if got := PrecisionAtK(top, relevant, 4); got != 0.5 {
+		t.Errorf("PrecisionAtK = %v, want 0.5", got)
+	}
+	if got := PrecisionAtK(top, relevant, 2); got != 0.5 {
+		t.Errorf("PrecisionAtK@2 = %v, want 0.5", got)
+	}
+	if got, want := RecallAtK(top, relevant, 4), 2.0/3; math.Abs(got-want) > 1e-9 {
+		t.Errorf("RecallAtK = %v, want %v", got, want)
+	}
+
+	dcg := 1/math.Log2(3) + 1/math.Log2(5)
+	ideal := 1 + 1/math.Log2(3) + 1/math.Log2(4)
+	if got := NDCGAtK(top, relevant, 4); math.Abs(got-dcg/ideal) > 1e-9 {
+		t.Errorf("NDCGAtK = %v, want %v", got, dcg/ideal)
+	}
+	if got := NDCGAtK([]int{2, 4, 9}, relevant, 3); math.Abs(got-1) > 1e-9 {
+		t.Errorf("NDCGAtK of the ideal ranking = %v, want 1", got)
+	}
+}
+
+func TestSyntheticEvaluation(t *testing.T) {
+	ds := Synthetic(200, 1)
+	if again := Synthetic(200, 1); len(again.Likes) != len(ds.Likes) {
+		t.Fatal("the same seed must give the same dataset")
+	}
+
+	strategy, err := scoring.BuildStrategy("balanced", scoring.DefaultConfig().Strategies["balanced"])
+	if err != nil {
+		t.Fatal(err)
+	}
+	report := Run(ds, strategy, 10)
+	if report.Viewers == 0 {
+		t.Fatal("no viewer with likes in the synthetic dataset")
+	}
+	for name, v := range map[string]float64{
+		"precision": report.Precision, "recall": report.Recall, "ndcg": report.NDCG,
+		"coverage": report.Coverage, "mutual match rate": report.MutualMatchRate,
+	} {
+		if v < 0 || v > 1 {
+			t.Errorf("%s = %v, want a value in [0, 1]", name, v)
+		}
+	}
+	if report.Precision == 0 {
+		t.Error("scoring should find some of the synthetic likes")
+	}
+}
+
+func TestServedReplay(t *testing.T) {
+	ds := Synthetic(200, 1)
+	strategy, err := scoring.BuildStrategy("balanced", scoring.DefaultConfig().Strategies["balanced"])
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// serve every viewer the strategy's own top 10 and have them dismiss the
+	// ones they did not like, half of the time
+	ds.Served = map[int][]int{}
+	ds.Dismissed = map[int]map[int]bool{}
+	var servedPairs, dismissedPairs int
+	for _, viewer := range ds.Users {
+		top := rank(viewer, ds.Users, strategy, 10)
+		ds.Served[viewer.UserID] = top
+		servedPairs += len(top)
+		for i, id := range top {
+			if !ds.Likes[viewer.UserID][id] && i%2 == 0 {
+				if ds.Dismissed[viewer.UserID] == nil {
+					ds.Dismissed[viewer.UserID] = map[int]bool{}
+				}
+				ds.Dismissed[viewer.UserID][id] = true
+				dismissedPairs++
+			}
+		}
+	}
+	// a viewer without a served list is not evaluated at all
+	delete(ds.Served, ds.Users[0].UserID)
+	for _, id := range rank(ds.Users[0], ds.Users, strategy, 10) {
+		if ds.Dismissed[ds.Users[0].UserID][id] {
+			dismissedPairs--
+		}
+		servedPairs--
+	}
+
+	report := Run(ds, strategy, 10)
+	if report.Viewers == 0 {
+		t.Fatal("no viewer liked anyone they were served")
+	}
+	// only likes among the served candidates are relevant, and all of them
+	// fit in the top 10
+	if math.Abs(report.Recall-1) > 1e-9 {
+		t.Errorf("Recall = %v, want 1 when every served candidate is ranked", report.Recall)
+	}
+	if want := float64(dismissedPairs) / float64(servedPairs); math.Abs(report.DismissalRate-want) > 1e-9 {
+		t.Errorf("DismissalRate = %v, want %v", report.DismissalRate, want)
+	}
+}
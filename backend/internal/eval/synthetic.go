@@ -0,0 +1,124 @@
+package eval
+
+import (
+	"math"
+	"math/rand"
+	"strings"
+	"time"
+
+	"matchme-backend/internal/models"
+	"matchme-backend/internal/utils"
+)
+
+var (
+	syntheticCities = map[string][]string{
+		"Estonia": {"Tallinn", "Tartu", "Narva", "Pärnu"},
+		"Germany": {"Berlin", "Hamburg", "Munich", "Frankfurt"},
+	}
+	syntheticCountries = []string{"Estonia", "Germany"}
+	syntheticHobbies   = []string{"Reading", "Gaming", "Cooking", "Art", "Sports", "Music", "Travel", "Photography"}
+	syntheticInterests = []string{"Movies", "Music", "Sports", "Coding", "Nature", "Pets", "Art", "Theatre"}
+	syntheticGenders   = []string{"male", "female", "other"}
+)
+
+// generates n users with complete profiles and likes drawn from a simple
+// ground truth: people mostly like those who fit their gender and age
+// preferences, more so the more hobbies and interests they share and when
+// they live in the same city; mutual likes become matches
+// the same seed always gives the same dataset
+func Synthetic(n int, seed int64) Dataset {
+	rng := rand.New(rand.NewSource(seed))
+	ds := Dataset{Likes: map[int]map[int]bool{}, Matches: map[int]map[int]bool{}}
+
+	for i := 1; i <= n; i++ {
+		country := syntheticCountries[rng.Intn(len(syntheticCountries))]
+		city := syntheticCities[country][rng.Intn(len(syntheticCities[country]))]
+		age := 18 + rng.Intn(40)
+		birthdate := time.Now().AddDate(-age, 0, -rng.Intn(300)-1).Format("2006-01-02")
+		gender := syntheticGenders[rng.Intn(len(syntheticGenders))]
+		lookingFor := "any"
+		if rng.Float64() < 0.7 {
+			lookingFor = syntheticGenders[rng.Intn(2)]
+		}
+		minAge := int(math.Max(18, float64(age-5-rng.Intn(5))))
+		maxAge := age + 5 + rng.Intn(10)
+		hobbies := sample(rng, syntheticHobbies, 3, 5)
+		interests := sample(rng, syntheticInterests, 3, 5)
+
+		ds.Users = append(ds.Users, models.User{
+			UserID:             i,
+			Gender:             &gender,
+			Birthdate:          &birthdate,
+			Country:            &country,
+			City:               &city,
+			LookingForGender:   &lookingFor,
+			LookingForMinAge:   &minAge,
+			LookingForMaxAge:   &maxAge,
+			Hobbies:            &hobbies,
+			Interests:          &interests,
+			PreferredHobbies:   &[]string{},
+			PreferredInterests: &[]string{},
+		})
+	}
+
+	for _, a := range ds.Users {
+		for _, b := range ds.Users {
+			if a.UserID == b.UserID || *a.Country != *b.Country {
+				continue
+			}
+			if rng.Float64() < likeProbability(a, b) {
+				if ds.Likes[a.UserID] == nil {
+					ds.Likes[a.UserID] = map[int]bool{}
+				}
+				ds.Likes[a.UserID][b.UserID] = true
+			}
+		}
+	}
+	for a, liked := range ds.Likes {
+		for b := range liked {
+			if ds.Likes[b][a] {
+				if ds.Matches[a] == nil {
+					ds.Matches[a] = map[int]bool{}
+				}
+				ds.Matches[a][b] = true
+			}
+		}
+	}
+	return ds
+}
+
+// the ground truth behind synthetic likes
+func likeProbability(viewer, target models.User) float64 {
+	age := utils.CalcAge(*target.Birthdate)
+	fits := (*viewer.LookingForGender == "any" || *viewer.LookingForGender == *target.Gender) &&
+		age >= *viewer.LookingForMinAge && age <= *viewer.LookingForMaxAge
+	if !fits {
+		return 0.01
+	}
+	shared := countShared(*viewer.Hobbies, *target.Hobbies) + countShared(*viewer.Interests, *target.Interests)
+	p := 0.02 + 0.05*float64(shared)
+	if strings.EqualFold(*viewer.City, *target.City) {
+		p *= 1.5
+	}
+	return math.Min(p, 0.6)
+}
+
+func countShared(a, b []string) int {
+	n := 0
+	for _, x := range a {
+		for _, y := range b {
+			if strings.EqualFold(x, y) {
+				n++
+				break
+			}
+		}
+	}
+	return n
+}
+
+// between min and max distinct items from options
+func sample(rng *rand.Rand, options []string, min, max int) []string {
+	shuffled := append([]string(nil), options...)
+	rng.Shuffle(len(shuffled), func(i, j int) { shuffled[i], shuffled[j] = shuffled[j], shuffled[i] })
+	return shuffled[:min+rng.Intn(max-min+1)]
+}
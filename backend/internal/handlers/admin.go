@@ -17,10 +17,6 @@ import (
 
 const adminSecret = "supersecureadminpassword"
 
-var interestsList = []string{"Movies", "Music", "Sports", "Coding", "Nature", "Pets", "Art", "Theatre"}
-var hobbiesList = []string{"Reading", "Gaming", "Cooking", "Art", "Sports", "Music", "Travel", "Photography"}
-var genders = []string{"male", "female", "other"}
-
 // generate a random user
 func generateFakeUser() models.User {
 	rand.Seed(time.Now().UnixNano())
@@ -83,8 +79,8 @@ func LoadFictitiousUsers(w http.ResponseWriter, r *http.Request) {
 
 	for _, user := range users {
 		_, err := tx.Exec(context.Background(), `
-			INSERT INTO users (email, password, fname, surname, gender, birthdate, about, hobbies, interests, country, city, looking_for_gender, looking_for_min_age, looking_for_max_age, profile_picture_url)
-			VALUES ($1, $2, $3, $4, $5, $6, $7, $8::jsonb, $9::jsonb, $10, $11, $12, $13, $14, $15)`,
+			INSERT INTO users (email, password, fname, surname, gender, birthdate, about, hobbies, interests, country, city, looking_for_gender, looking_for_min_age, looking_for_max_age, profile_picture_url, profile_completed_at)
+			VALUES ($1, $2, $3, $4, $5, $6, $7, $8::jsonb, $9::jsonb, $10, $11, $12, $13, $14, $15, NOW())`,
 			user.Email, user.Password, user.Fname, user.Surname, user.Gender, user.Birthdate,
 			user.About, toJSON(user.Hobbies), toJSON(user.Interests), user.Country, user.City,
 			user.LookingForGender, user.LookingForMinAge, user.LookingForMaxAge, user.Picture,
@@ -140,6 +136,8 @@ func randomString(n int) string {
 func randomSelection(slice []string, min, max int) []string {
 	rand.Seed(time.Now().UnixNano())
 	n := rand.Intn(max-min+1) + min
-	rand.Shuffle(len(slice), func(i, j int) { slice[i], slice[j] = slice[j], slice[i] })
-	return slice[:n]
+	// shuffle a copy so the shared option lists keep their order
+	shuffled := append([]string(nil), slice...)
+	rand.Shuffle(len(shuffled), func(i, j int) { shuffled[i], shuffled[j] = shuffled[j], shuffled[i] })
+	return shuffled[:n]
 }
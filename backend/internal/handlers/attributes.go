@@ -0,0 +1,350 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"math"
+	"net/http"
+	"sort"
+	"strings"
+
+	"github.com/jackc/pgx/v5"
+
+	"matchme-backend/internal/db"
+	"matchme-backend/internal/models"
+)
+
+const (
+	attributeInt       = "int"
+	attributeEnum      = "enum"
+	attributeMultiEnum = "multi_enum"
+	attributeBool      = "bool"
+)
+
+// lists the attribute registry so clients can render profile and filter forms
+func AttributesHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	defs, err := loadAttributeDefinitions()
+	if err != nil {
+		log.Printf("Error loading attribute definitions: %v\n", err)
+		http.Error(w, "Error retrieving attributes", http.StatusInternalServerError)
+		return
+	}
+
+	list := make([]models.AttributeDefinition, 0, len(defs))
+	for _, d := range defs {
+		list = append(list, d)
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].ID < list[j].ID })
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(list)
+}
+
+// returns the attribute registry keyed by name
+func loadAttributeDefinitions() (map[string]models.AttributeDefinition, error) {
+	rows, err := db.Pool.Query(context.Background(), `
+        SELECT id, name, label, value_type, allowed_values, min_value, max_value, filterable, dealbreaker
+        FROM attribute_definitions
+    `)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	defs := make(map[string]models.AttributeDefinition)
+	for rows.Next() {
+		var d models.AttributeDefinition
+		var allowed *[]string
+		if err := rows.Scan(&d.ID, &d.Name, &d.Label, &d.Type, &allowed, &d.MinValue, &d.MaxValue, &d.Filterable, &d.Dealbreaker); err != nil {
+			return nil, err
+		}
+		if allowed != nil {
+			d.AllowedValues = *allowed
+		}
+		defs[d.Name] = d
+	}
+	return defs, rows.Err()
+}
+
+// loads attribute values for the given users, keyed by user ID and attribute name
+func loadUserAttributes(userIDs []int) (map[int]map[string]interface{}, error) {
+	result := make(map[int]map[string]interface{})
+	if len(userIDs) == 0 {
+		return result, nil
+	}
+
+	rows, err := db.Pool.Query(context.Background(), `
+        SELECT ua.user_id, d.name, ua.value
+        FROM user_attributes ua
+        JOIN attribute_definitions d ON d.id = ua.attribute_id
+        WHERE ua.user_id = ANY($1)
+    `, userIDs)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var userID int
+		var name string
+		var raw []byte
+		if err := rows.Scan(&userID, &name, &raw); err != nil {
+			return nil, err
+		}
+		var value interface{}
+		if err := json.Unmarshal(raw, &value); err != nil {
+			return nil, err
+		}
+		if result[userID] == nil {
+			result[userID] = make(map[string]interface{})
+		}
+		result[userID][name] = value
+	}
+	return result, rows.Err()
+}
+
+// loads the partner filters the given users have set, keyed by user ID and attribute name
+func loadAttributeFilters(userIDs []int) (map[int]map[string]*models.AttributeFilter, error) {
+	result := make(map[int]map[string]*models.AttributeFilter)
+	if len(userIDs) == 0 {
+		return result, nil
+	}
+
+	rows, err := db.Pool.Query(context.Background(), `
+        SELECT f.user_id, d.name, f.accepted_values, f.min_value, f.max_value, f.dealbreaker
+        FROM user_attribute_filters f
+        JOIN attribute_definitions d ON d.id = f.attribute_id
+        WHERE f.user_id = ANY($1)
+    `, userIDs)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var userID int
+		var name string
+		var values *[]string
+		f := &models.AttributeFilter{}
+		if err := rows.Scan(&userID, &name, &values, &f.Min, &f.Max, &f.Dealbreaker); err != nil {
+			return nil, err
+		}
+		if values != nil {
+			f.Values = *values
+		}
+		if result[userID] == nil {
+			result[userID] = make(map[string]*models.AttributeFilter)
+		}
+		result[userID][name] = f
+	}
+	return result, rows.Err()
+}
+
+// fills in the attributes and partner filters of users loaded from the users table
+func attachAttributes(users []models.User) error {
+	ids := make([]int, len(users))
+	for i, u := range users {
+		ids[i] = u.UserID
+	}
+	attrs, err := loadUserAttributes(ids)
+	if err != nil {
+		return err
+	}
+	filters, err := loadAttributeFilters(ids)
+	if err != nil {
+		return err
+	}
+	for i := range users {
+		users[i].Attributes = attrs[users[i].UserID]
+		users[i].AttributeFilters = filters[users[i].UserID]
+	}
+	return nil
+}
+
+// checks attribute values and filters from a profile update against the registry
+// and rewrites them into their canonical form
+func validateAttributes(errs ValidationErrors, u *models.User, defs map[string]models.AttributeDefinition) {
+	for name, value := range u.Attributes {
+		field := "attributes." + name
+		def, ok := defs[name]
+		if !ok {
+			errs.add(field, "unknown attribute")
+			continue
+		}
+		if value == nil {
+			continue // removes the attribute
+		}
+		normalized, msg := normalizeAttributeValue(def, value)
+		if msg != "" {
+			errs.add(field, "%s", msg)
+			continue
+		}
+		u.Attributes[name] = normalized
+	}
+
+	for name, f := range u.AttributeFilters {
+		field := "attribute_filters." + name
+		def, ok := defs[name]
+		if !ok {
+			errs.add(field, "unknown attribute")
+			continue
+		}
+		if f == nil {
+			continue // removes the filter
+		}
+		if !def.Filterable {
+			errs.add(field, "attribute cannot be used as a filter")
+			continue
+		}
+		if f.Dealbreaker && !def.Dealbreaker {
+			errs.add(field, "attribute cannot be a dealbreaker")
+			continue
+		}
+		if msg := normalizeAttributeFilter(def, f); msg != "" {
+			errs.add(field, "%s", msg)
+		}
+	}
+}
+
+// returns the value in its stored form, or an error message
+func normalizeAttributeValue(def models.AttributeDefinition, value interface{}) (interface{}, string) {
+	switch def.Type {
+	case attributeInt:
+		n, ok := value.(float64)
+		if !ok || n != math.Trunc(n) {
+			return nil, "must be a whole number"
+		}
+		if (def.MinValue != nil && int(n) < *def.MinValue) || (def.MaxValue != nil && int(n) > *def.MaxValue) {
+			return nil, fmt.Sprintf("must be between %s and %s", boundString(def.MinValue), boundString(def.MaxValue))
+		}
+		return int(n), ""
+	case attributeEnum:
+		s, ok := value.(string)
+		if !ok || !containsFold(def.AllowedValues, s) {
+			return nil, "must be one of " + strings.Join(def.AllowedValues, ", ")
+		}
+		return canonicalOption(def.AllowedValues, s), ""
+	case attributeMultiEnum:
+		items, ok := value.([]interface{})
+		if !ok {
+			return nil, "must be a list"
+		}
+		var list []string
+		for _, item := range items {
+			s, ok := item.(string)
+			if !ok || !containsFold(def.AllowedValues, s) {
+				return nil, "values must be among " + strings.Join(def.AllowedValues, ", ")
+			}
+			list = append(list, s)
+		}
+		return *dedupeOptions(&list, def.AllowedValues), ""
+	case attributeBool:
+		b, ok := value.(bool)
+		if !ok {
+			return nil, "must be true or false"
+		}
+		return b, ""
+	}
+	return nil, "unsupported attribute type"
+}
+
+func normalizeAttributeFilter(def models.AttributeDefinition, f *models.AttributeFilter) string {
+	switch def.Type {
+	case attributeInt:
+		if len(f.Values) > 0 {
+			return "use min and max for this attribute"
+		}
+		if f.Min == nil && f.Max == nil {
+			return "min or max is required"
+		}
+		if f.Min != nil && f.Max != nil && *f.Min > *f.Max {
+			return "min cannot be greater than max"
+		}
+	case attributeEnum, attributeMultiEnum, attributeBool:
+		if f.Min != nil || f.Max != nil {
+			return "use values for this attribute"
+		}
+		allowed := def.AllowedValues
+		if def.Type == attributeBool {
+			allowed = []string{"true", "false"}
+		}
+		if len(f.Values) == 0 {
+			return "at least one value is required"
+		}
+		for _, v := range f.Values {
+			if !containsFold(allowed, v) {
+				return "values must be among " + strings.Join(allowed, ", ")
+			}
+		}
+		f.Values = *dedupeOptions(&f.Values, allowed)
+	default:
+		return "unsupported attribute type"
+	}
+	return ""
+}
+
+func boundString(b *int) string {
+	if b == nil {
+		return "any"
+	}
+	return fmt.Sprint(*b)
+}
+
+// writes attribute values and filters from a profile update; nil entries are removed
+func saveUserAttributes(tx pgx.Tx, u models.User, defs map[string]models.AttributeDefinition) error {
+	ctx := context.Background()
+	for name, value := range u.Attributes {
+		def := defs[name]
+		if value == nil {
+			if _, err := tx.Exec(ctx, `
+                DELETE FROM user_attributes WHERE user_id = $1 AND attribute_id = $2
+            `, u.UserID, def.ID); err != nil {
+				return err
+			}
+			continue
+		}
+		if _, err := tx.Exec(ctx, `
+            INSERT INTO user_attributes (user_id, attribute_id, value, updated_at)
+            VALUES ($1, $2, $3::jsonb, NOW())
+            ON CONFLICT (user_id, attribute_id)
+            DO UPDATE SET value = EXCLUDED.value, updated_at = NOW()
+        `, u.UserID, def.ID, toJSON(value)); err != nil {
+			return err
+		}
+	}
+
+	for name, f := range u.AttributeFilters {
+		def := defs[name]
+		if f == nil {
+			if _, err := tx.Exec(ctx, `
+                DELETE FROM user_attribute_filters WHERE user_id = $1 AND attribute_id = $2
+            `, u.UserID, def.ID); err != nil {
+				return err
+			}
+			continue
+		}
+		var values *[]string
+		if len(f.Values) > 0 {
+			values = &f.Values
+		}
+		if _, err := tx.Exec(ctx, `
+            INSERT INTO user_attribute_filters (user_id, attribute_id, accepted_values, min_value, max_value, dealbreaker)
+            VALUES ($1, $2, $3, $4, $5, $6)
+            ON CONFLICT (user_id, attribute_id)
+            DO UPDATE SET accepted_values = EXCLUDED.accepted_values,
+                          min_value = EXCLUDED.min_value,
+                          max_value = EXCLUDED.max_value,
+                          dealbreaker = EXCLUDED.dealbreaker
+        `, u.UserID, def.ID, values, f.Min, f.Max, f.Dealbreaker); err != nil {
+			return err
+		}
+	}
+	return nil
+}
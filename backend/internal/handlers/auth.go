@@ -3,6 +3,7 @@ package handlers
 import (
 	"context"
 	"encoding/json"
+	"log"
 	"matchme-backend/internal/db"
 	"matchme-backend/internal/models"
 	"matchme-backend/internal/utils"
@@ -43,6 +44,18 @@ func LoginHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	// suspended and banned users are told why only after proving who they are
+	sanction, err := utils.ActiveSanction(user.UserID)
+	if err != nil {
+		log.Printf("Error checking sanctions for user %d: %v\n", user.UserID, err)
+		http.Error(w, "Internal Server Error", http.StatusInternalServerError)
+		return
+	}
+	if sanction != nil {
+		utils.WriteSanction(w, sanction)
+		return
+	}
+
 	token, err := utils.GenerateToken(user.UserID, user.Email)
 	if err != nil {
 		http.Error(w, "Internal Server Error", http.StatusInternalServerError)
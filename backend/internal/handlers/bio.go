@@ -0,0 +1,237 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"log"
+	"net/http"
+	"strconv"
+
+	"github.com/jackc/pgx/v5"
+
+	"matchme-backend/internal/db"
+	"matchme-backend/internal/models"
+	"matchme-backend/internal/text"
+	"matchme-backend/internal/utils"
+)
+
+// replaces the user's indexed bio terms; document counts follow through a trigger
+func indexBio(tx pgx.Tx, userID int, about string) error {
+	ctx := context.Background()
+	if _, err := tx.Exec(ctx, `DELETE FROM bio_terms WHERE user_id = $1`, userID); err != nil {
+		return err
+	}
+	_, err := tx.Exec(ctx, `
+        INSERT INTO bio_documents (user_id, indexed_at) VALUES ($1, NOW())
+        ON CONFLICT (user_id) DO UPDATE SET indexed_at = NOW()
+    `, userID)
+	if err != nil {
+		return err
+	}
+
+	batch := &pgx.Batch{}
+	for term, count := range text.TermCounts(text.Tokenize(about)) {
+		batch.Queue(`INSERT INTO bio_terms (user_id, term, count) VALUES ($1, $2, $3)`, userID, term, count)
+	}
+	return tx.SendBatch(ctx, batch).Close()
+}
+
+// indexes up to limit users whose bio was never indexed (e.g. created before
+// the index existed or loaded by the admin tools); returns how many were indexed
+func IndexMissingBios(limit int) (int, error) {
+	ctx := context.Background()
+	rows, err := db.Pool.Query(ctx, `
+        SELECT u.id, COALESCE(u.about, '')
+        FROM users u
+        WHERE NOT EXISTS (SELECT 1 FROM bio_documents d WHERE d.user_id = u.id)
+        ORDER BY u.id
+        LIMIT $1
+    `, limit)
+	if err != nil {
+		return 0, err
+	}
+	type bio struct {
+		userID int
+		about  string
+	}
+	var bios []bio
+	for rows.Next() {
+		var b bio
+		if err := rows.Scan(&b.userID, &b.about); err != nil {
+			rows.Close()
+			return 0, err
+		}
+		bios = append(bios, b)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return 0, err
+	}
+
+	indexed := 0
+	for _, b := range bios {
+		tx, err := db.Pool.Begin(ctx)
+		if err != nil {
+			return indexed, err
+		}
+		if err := indexBio(tx, b.userID, b.about); err != nil {
+			tx.Rollback(ctx)
+			return indexed, err
+		}
+		if err := tx.Commit(ctx); err != nil {
+			return indexed, err
+		}
+		indexed++
+	}
+	return indexed, nil
+}
+
+// fills in the TF-IDF vector of each user's bio
+func attachBioVectors(users []models.User) error {
+	if len(users) == 0 {
+		return nil
+	}
+	ids := make([]int, len(users))
+	for i, u := range users {
+		ids[i] = u.UserID
+	}
+
+	ctx := context.Background()
+	var total int
+	if err := db.Pool.QueryRow(ctx, `SELECT COUNT(*) FROM bio_documents`).Scan(&total); err != nil {
+		return err
+	}
+	rows, err := db.Pool.Query(ctx, `
+        SELECT t.user_id, t.term, t.count, COALESCE(s.document_count, 1)
+        FROM bio_terms t
+        LEFT JOIN bio_term_stats s ON s.term = t.term
+        WHERE t.user_id = ANY($1)
+    `, ids)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	vectors := make(map[int]map[string]float64)
+	for rows.Next() {
+		var userID, count, documentCount int
+		var term string
+		if err := rows.Scan(&userID, &term, &count, &documentCount); err != nil {
+			return err
+		}
+		if vectors[userID] == nil {
+			vectors[userID] = make(map[string]float64)
+		}
+		vectors[userID][term] = text.TFIDF(count, documentCount, total)
+	}
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	for i := range users {
+		users[i].BioVector = vectors[users[i].UserID]
+	}
+	return nil
+}
+
+// TF-IDF vectors of texts that are not (yet) indexed, weighted by the
+// document counts of the indexed bios
+func bioTextVectors(texts []string) ([]map[string]float64, error) {
+	counts := make([]map[string]int, len(texts))
+	var terms []string
+	seen := make(map[string]bool)
+	for i, t := range texts {
+		counts[i] = text.TermCounts(text.Tokenize(t))
+		for term := range counts[i] {
+			if !seen[term] {
+				seen[term] = true
+				terms = append(terms, term)
+			}
+		}
+	}
+
+	ctx := context.Background()
+	var total int
+	if err := db.Pool.QueryRow(ctx, `SELECT COUNT(*) FROM bio_documents`).Scan(&total); err != nil {
+		return nil, err
+	}
+	rows, err := db.Pool.Query(ctx, `
+        SELECT term, document_count FROM bio_term_stats WHERE term = ANY($1)
+    `, terms)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	documentCounts := make(map[string]int)
+	for rows.Next() {
+		var term string
+		var documentCount int
+		if err := rows.Scan(&term, &documentCount); err != nil {
+			return nil, err
+		}
+		documentCounts[term] = documentCount
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	vectors := make([]map[string]float64, len(texts))
+	for i, c := range counts {
+		vectors[i] = make(map[string]float64, len(c))
+		for term, count := range c {
+			documentCount := documentCounts[term]
+			if documentCount == 0 {
+				documentCount = 1
+			}
+			vectors[i][term] = text.TFIDF(count, documentCount, total)
+		}
+	}
+	return vectors, nil
+}
+
+// GET /me/tag-suggestions[?about=...] suggests hobbies and interests mentioned
+// in the bio (the saved one, or the given draft) that the user has not picked yet
+func TagSuggestionsHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	userIDStr, err := utils.ExtractUserIDFromTokenFromCookie(r)
+	if err != nil {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+	userID, _ := strconv.Atoi(userIDStr)
+
+	user, err := getUserByID(userID)
+	if err != nil {
+		log.Printf("Error fetching user for tag suggestions: %v\n", err)
+		http.Error(w, "Error retrieving suggestions", http.StatusInternalServerError)
+		return
+	}
+	about := ""
+	if user.About != nil {
+		about = *user.About
+	}
+	if draft, ok := r.URL.Query()["about"]; ok {
+		about = draft[0]
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string][]string{
+		"hobbies":   newTags(text.SuggestTags(about, hobbiesList, text.TagKeywords), user.Hobbies),
+		"interests": newTags(text.SuggestTags(about, interestsList, text.TagKeywords), user.Interests),
+	})
+}
+
+// the suggested tags not already in the user's list
+func newTags(suggested []string, current *[]string) []string {
+	result := []string{}
+	for _, tag := range suggested {
+		if current == nil || !containsFold(*current, tag) {
+			result = append(result, tag)
+		}
+	}
+	return result
+}
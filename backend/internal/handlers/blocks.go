@@ -0,0 +1,207 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"matchme-backend/internal/db"
+	"matchme-backend/internal/utils"
+)
+
+// SQL returning everyone the user ($1) blocked or was blocked by
+const blockedUsersQuery = `
+    SELECT blocked_id FROM blocks WHERE blocker_id = $1
+    UNION
+    SELECT blocker_id FROM blocks WHERE blocked_id = $1`
+
+// reports whether either user blocked the other
+func isBlocked(a, b int) (bool, error) {
+	var blocked bool
+	err := db.Pool.QueryRow(context.Background(), `
+        SELECT EXISTS (
+            SELECT 1 FROM blocks
+            WHERE (blocker_id = $1 AND blocked_id = $2)
+               OR (blocker_id = $2 AND blocked_id = $1)
+        )
+    `, a, b).Scan(&blocked)
+	return blocked, err
+}
+
+// returns everyone the user blocked or was blocked by
+func loadBlockedIDs(userID int) (map[int]bool, error) {
+	rows, err := db.Pool.Query(context.Background(), blockedUsersQuery, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	blocked := make(map[int]bool)
+	for rows.Next() {
+		var id int
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		blocked[id] = true
+	}
+	return blocked, rows.Err()
+}
+
+type block struct {
+	BlockedUserID int    `json:"blockedUserId"`
+	BlockedAt     string `json:"blocked_at"`
+}
+
+// GET lists the users the viewer blocked, POST blocks one, DELETE unblocks one
+func BlocksHandler(w http.ResponseWriter, r *http.Request) {
+	userIDStr, err := utils.ExtractUserIDFromTokenFromCookie(r)
+	if err != nil {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+	userID, _ := strconv.Atoi(userIDStr)
+
+	switch r.Method {
+	case http.MethodGet:
+		listBlocks(w, userID)
+	case http.MethodPost:
+		blockUser(w, r, userID)
+	case http.MethodDelete:
+		unblockUser(w, r, userID)
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+func listBlocks(w http.ResponseWriter, userID int) {
+	rows, err := db.Pool.Query(context.Background(), `
+        SELECT blocked_id, created_at::timestamptz
+        FROM blocks
+        WHERE blocker_id = $1
+        ORDER BY created_at DESC, blocked_id
+    `, userID)
+	if err != nil {
+		log.Printf("Error fetching blocks: %v\n", err)
+		http.Error(w, "Error retrieving blocks", http.StatusInternalServerError)
+		return
+	}
+	defer rows.Close()
+
+	blocks := []block{}
+	for rows.Next() {
+		var b block
+		var blockedAt time.Time
+		if err := rows.Scan(&b.BlockedUserID, &blockedAt); err != nil {
+			log.Printf("Error scanning block: %v\n", err)
+			http.Error(w, "Error retrieving blocks", http.StatusInternalServerError)
+			return
+		}
+		b.BlockedAt = blockedAt.UTC().Format(time.RFC3339)
+		blocks = append(blocks, b)
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(blocks)
+}
+
+// blocks the target and removes everything that connects the two users:
+// requests and connections, and recommendations in both directions
+func blockUser(w http.ResponseWriter, r *http.Request, userID int) {
+	var body struct {
+		TargetUserID int `json:"targetUserId"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		http.Error(w, "Invalid request payload", http.StatusBadRequest)
+		return
+	}
+	if body.TargetUserID == userID {
+		http.Error(w, "Cannot block yourself", http.StatusBadRequest)
+		return
+	}
+
+	ctx := context.Background()
+	tx, err := db.Pool.Begin(ctx)
+	if err != nil {
+		http.Error(w, "Error blocking user", http.StatusInternalServerError)
+		return
+	}
+	defer tx.Rollback(ctx)
+
+	tag, err := tx.Exec(ctx, `
+        INSERT INTO blocks (blocker_id, blocked_id)
+        SELECT $1, id FROM users WHERE id = $2
+        ON CONFLICT (blocker_id, blocked_id) DO NOTHING
+    `, userID, body.TargetUserID)
+	if err != nil {
+		log.Printf("Error blocking user: %v\n", err)
+		http.Error(w, "Error blocking user", http.StatusInternalServerError)
+		return
+	}
+	if tag.RowsAffected() == 0 {
+		var exists bool
+		tx.QueryRow(ctx, `SELECT EXISTS (SELECT 1 FROM users WHERE id = $1)`, body.TargetUserID).Scan(&exists)
+		if !exists {
+			http.Error(w, "User not found", http.StatusNotFound)
+			return
+		}
+	}
+
+	for _, query := range []string{
+		`DELETE FROM connections
+         WHERE (user_id = $1 AND connected_user_id = $2)
+            OR (user_id = $2 AND connected_user_id = $1)`,
+		`DELETE FROM recommendations
+         WHERE (user_id = $1 AND recommended_user_id = $2)
+            OR (user_id = $2 AND recommended_user_id = $1)`,
+	} {
+		if _, err := tx.Exec(ctx, query, userID, body.TargetUserID); err != nil {
+			log.Printf("Error blocking user: %v\n", err)
+			http.Error(w, "Error blocking user", http.StatusInternalServerError)
+			return
+		}
+	}
+	if err := tx.Commit(ctx); err != nil {
+		log.Printf("Error committing block: %v\n", err)
+		http.Error(w, "Error blocking user", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]string{
+		"message": "User blocked successfully",
+	})
+}
+
+// lifts the viewer's block; the other user may still have blocked the viewer
+func unblockUser(w http.ResponseWriter, r *http.Request, userID int) {
+	var body struct {
+		TargetUserID int `json:"targetUserId"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		http.Error(w, "Invalid request payload", http.StatusBadRequest)
+		return
+	}
+
+	tag, err := db.Pool.Exec(context.Background(), `
+        DELETE FROM blocks WHERE blocker_id = $1 AND blocked_id = $2
+    `, userID, body.TargetUserID)
+	if err != nil {
+		log.Printf("Error unblocking user: %v\n", err)
+		http.Error(w, "Error unblocking user", http.StatusInternalServerError)
+		return
+	}
+	if tag.RowsAffected() == 0 {
+		http.Error(w, "Block not found", http.StatusNotFound)
+		return
+	}
+	markRecommendationsStale(userID)
+	markRecommendationsStale(body.TargetUserID)
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]string{
+		"message": "User unblocked successfully",
+	})
+}
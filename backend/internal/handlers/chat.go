@@ -65,16 +65,16 @@ func ChatWebSocketHandler(w http.ResponseWriter, r *http.Request) {
 
 	log.Printf("Received token in WS connect message: %s\n", connectMsg.Token)
 
-	// validate the token
-	userIDStr, err := utils.ExtractUserIDFromToken(connectMsg.Token)
+	// validate the token; suspended and banned users cannot chat
+	userID, sanction, err := utils.AuthenticateToken(connectMsg.Token)
 	if err != nil {
 		log.Println("WebSocket authentication failed:", err)
 		conn.Close()
 		return
 	}
-	userID, err := strconv.Atoi(userIDStr)
-	if err != nil {
-		log.Println("Invalid user ID from token:", err)
+	if sanction != nil {
+		log.Printf("Sanctioned user %d refused on WebSocket\n", userID)
+		sendWSMessage(conn, WSMessage{Type: "account_sanctioned"})
 		conn.Close()
 		return
 	}
@@ -83,6 +83,14 @@ func ChatWebSocketHandler(w http.ResponseWriter, r *http.Request) {
 	clients[userID] = conn
 	onlineUsers[userID] = true
 	clientsMutex.Unlock()
+	utils.TouchLastActive(userID)
+
+	// an open connection counts as presence: keep last_active_at fresh on every pong
+	conn.SetPongHandler(func(appData string) error {
+		conn.SetReadDeadline(time.Now().Add(pongWait))
+		utils.TouchLastActive(userID)
+		return nil
+	})
 
 	log.Printf("User %d connected via WebSocket\n", userID)
 
@@ -123,6 +131,7 @@ func ChatWebSocketHandler(w http.ResponseWriter, r *http.Request) {
 			log.Printf("WebSocket read error for user %d: %v\n", userID, err)
 			break
 		}
+		utils.TouchLastActive(userID)
 
 		switch msg.Type {
 		case "message":
@@ -130,6 +139,14 @@ func ChatWebSocketHandler(w http.ResponseWriter, r *http.Request) {
 				log.Printf("User %d attempted to send message with mismatched sender ID\n", userID)
 				continue
 			}
+			if blocked, err := isBlocked(userID, msg.ReceiverID); err != nil || blocked {
+				if err != nil {
+					log.Printf("Error checking blocks for user %d: %v\n", userID, err)
+				}
+				// blocks are not revealed to the sender: the message is dropped
+				// without a reply, just as a message to someone offline gets none
+				continue
+			}
 			msg.Timestamp = time.Now().Format(time.RFC3339)
 			savedMsg, err := saveChatMessage(msg)
 			if err != nil {
@@ -153,6 +170,9 @@ func ChatWebSocketHandler(w http.ResponseWriter, r *http.Request) {
 				log.Printf("User %d is offline; message stored for later delivery\n", msg.ReceiverID)
 			}
 		case "typing":
+			if blocked, err := isBlocked(userID, msg.ReceiverID); err != nil || blocked {
+				continue
+			}
 			clientsMutex.Lock()
 			if receiverConn, ok := clients[msg.ReceiverID]; ok {
 				sendWSMessage(receiverConn, WSMessage{
@@ -180,6 +200,22 @@ DISCONNECT:
 	log.Printf("User %d disconnected from WebSocket\n", userID)
 }
 
+// closes the user's live connection, if any, after telling the client why;
+// used when the user is suspended or banned
+func disconnectUser(userID int) {
+	clientsMutex.Lock()
+	conn, online := clients[userID]
+	delete(clients, userID)
+	delete(onlineUsers, userID)
+	clientsMutex.Unlock()
+	if !online {
+		return
+	}
+	sendWSMessage(conn, WSMessage{Type: "account_sanctioned"})
+	conn.Close()
+	log.Printf("User %d disconnected from WebSocket by a moderator\n", userID)
+}
+
 // writes a JSON message to the WebSocket
 func sendWSMessage(conn *websocket.Conn, msg WSMessage) {
 	conn.SetWriteDeadline(time.Now().Add(writeWait))
@@ -215,6 +251,7 @@ func fetchUndeliveredMessages(userID int) ([]models.Chat, error) {
 		SELECT id, sender_id, receiver_id, message, TO_CHAR(created_at, 'YYYY-MM-DD"T"HH24:MI:SS"Z"') as created_at, delivered
 		FROM chats
 		WHERE receiver_id = $1 AND delivered = false
+		  AND sender_id NOT IN (`+blockedUsersQuery+`)
 		ORDER BY created_at ASC
 	`, userID)
 	if err != nil {
@@ -306,6 +343,7 @@ func UnreadMessagesHandler(w http.ResponseWriter, r *http.Request) {
 		SELECT sender_id, COUNT(*)
 		FROM chats
 		WHERE receiver_id = $1 AND delivered = false
+		  AND sender_id NOT IN (`+blockedUsersQuery+`)
 		GROUP BY sender_id
 	`, userID)
 	if err != nil {
@@ -324,9 +362,30 @@ func UnreadMessagesHandler(w http.ResponseWriter, r *http.Request) {
 	json.NewEncoder(w).Encode(unread)
 }
 
+// lists who is online, leaving out users blocked in either direction
 func OnlineStatusHandler(w http.ResponseWriter, r *http.Request) {
-	w.Header().Set("Content-Type", "application/json")
+	userIDStr, err := utils.ExtractUserIDFromTokenFromCookie(r)
+	if err != nil {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+	userID, _ := strconv.Atoi(userIDStr)
+	blocked, err := loadBlockedIDs(userID)
+	if err != nil {
+		log.Printf("Error loading blocks: %v\n", err)
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+
 	clientsMutex.Lock()
-	defer clientsMutex.Unlock()
-	json.NewEncoder(w).Encode(onlineUsers)
+	online := make(map[int]bool, len(onlineUsers))
+	for id, isOnline := range onlineUsers {
+		if !blocked[id] {
+			online[id] = isOnline
+		}
+	}
+	clientsMutex.Unlock()
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(online)
 }
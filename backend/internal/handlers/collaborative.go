@@ -0,0 +1,193 @@
+package handlers
+
+import (
+	"context"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+
+	"matchme-backend/internal/db"
+	"matchme-backend/internal/models"
+	"matchme-backend/internal/scoring"
+)
+
+const (
+	// users whose reactions share fewer targets count proportionally less
+	similarityMinOverlap = 3
+	// neighbours kept per user
+	similarityNeighbors = 50
+	// added to the similarity total so a single neighbour cannot give full affinity
+	affinityShrinkage = 1.0
+)
+
+// every like (connection request, whatever its status) and dismissal as
+// (user_id, target_id, value); a like wins over a dismissal of the same person
+var reactionsQuery = `
+    SELECT user_id, connected_user_id AS target_id, 1.0::float8 AS value
+    FROM connections
+    UNION ALL
+    SELECT d.user_id, d.dismissed_user_id, -1.0::float8
+    FROM dismissed_recommendations d
+    WHERE NOT EXISTS (
+        SELECT 1 FROM connections c
+        WHERE c.user_id = d.user_id AND c.connected_user_id = d.dismissed_user_id
+    )`
+
+// rebuilds user_similarity from all likes and dismissals
+// returns how many users got neighbours
+func ComputeUserSimilarities() (int, error) {
+	ctx := context.Background()
+	rows, err := db.Pool.Query(ctx, reactionsQuery)
+	if err != nil {
+		return 0, err
+	}
+	reactions := make(map[int]map[int]float64)
+	for rows.Next() {
+		var userID, targetID int
+		var value float64
+		if err := rows.Scan(&userID, &targetID, &value); err != nil {
+			rows.Close()
+			return 0, err
+		}
+		if reactions[userID] == nil {
+			reactions[userID] = make(map[int]float64)
+		}
+		reactions[userID][targetID] = value
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return 0, err
+	}
+
+	neighbors := scoring.UserSimilarities(reactions, similarityMinOverlap, similarityNeighbors)
+
+	now := time.Now()
+	var copyRows [][]interface{}
+	for userID, list := range neighbors {
+		for _, n := range list {
+			copyRows = append(copyRows, []interface{}{userID, n.UserID, n.Similarity, now})
+		}
+	}
+
+	tx, err := db.Pool.Begin(ctx)
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback(ctx)
+
+	if _, err := tx.Exec(ctx, `DELETE FROM user_similarity`); err != nil {
+		return 0, err
+	}
+	_, err = tx.CopyFrom(ctx,
+		pgx.Identifier{"user_similarity"},
+		[]string{"user_id", "similar_user_id", "similarity", "computed_at"},
+		pgx.CopyFromRows(copyRows),
+	)
+	if err != nil {
+		return 0, err
+	}
+	return len(neighbors), tx.Commit(ctx)
+}
+
+// predicts from the viewer's neighbours how much the viewer will like each
+// user they reacted to, and from each other user's neighbours how much that
+// user will like the viewer; both in [-1, 1] and keyed by the other user's ID
+func loadAffinities(viewerID int) (forward, reverse map[int]float64, err error) {
+	forward, err = queryAffinities(`
+        WITH reactions AS (`+reactionsQuery+`)
+        SELECT r.target_id, SUM(s.similarity * r.value) / (SUM(s.similarity) + $2)
+        FROM user_similarity s
+        JOIN reactions r ON r.user_id = s.similar_user_id
+        WHERE s.user_id = $1
+        GROUP BY r.target_id
+    `, viewerID)
+	if err != nil {
+		return nil, nil, err
+	}
+	reverse, err = queryAffinities(`
+        WITH reactions AS (`+reactionsQuery+`)
+        SELECT s.user_id, SUM(s.similarity * r.value) / (SUM(s.similarity) + $2)
+        FROM user_similarity s
+        JOIN reactions r ON r.user_id = s.similar_user_id
+        WHERE r.target_id = $1
+        GROUP BY s.user_id
+    `, viewerID)
+	return forward, reverse, err
+}
+
+func queryAffinities(query string, viewerID int) (map[int]float64, error) {
+	rows, err := db.Pool.Query(context.Background(), query, viewerID, affinityShrinkage)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	affinities := make(map[int]float64)
+	for rows.Next() {
+		var id int
+		var affinity float64
+		if err := rows.Scan(&id, &affinity); err != nil {
+			return nil, err
+		}
+		affinities[id] = affinity
+	}
+	return affinities, rows.Err()
+}
+
+// fills in affinities for the viewer and, for reciprocal scoring, each
+// candidate's affinity for the viewer; skipped when the strategy ignores them
+func attachAffinities(viewer *models.User, candidates []models.User, strategy *scoring.Strategy) error {
+	if !strategy.Uses(scoring.CollaborativeSignal{}.Name()) {
+		return nil
+	}
+	forward, reverse, err := loadAffinities(viewer.UserID)
+	if err != nil {
+		return err
+	}
+	viewer.Affinities = forward
+	for i := range candidates {
+		if a, ok := reverse[candidates[i].UserID]; ok {
+			candidates[i].Affinities = map[int]float64{viewer.UserID: a}
+		}
+	}
+	return nil
+}
+
+// fills in every user's affinities for all the others at once, for offline
+// evaluation; skipped when the strategy ignores them
+func attachAllAffinities(users []models.User, strategy *scoring.Strategy) error {
+	if !strategy.Uses(scoring.CollaborativeSignal{}.Name()) {
+		return nil
+	}
+	rows, err := db.Pool.Query(context.Background(), `
+        WITH reactions AS (`+reactionsQuery+`)
+        SELECT s.user_id, r.target_id, SUM(s.similarity * r.value) / (SUM(s.similarity) + $1)
+        FROM user_similarity s
+        JOIN reactions r ON r.user_id = s.similar_user_id
+        GROUP BY s.user_id, r.target_id
+    `, affinityShrinkage)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	affinities := make(map[int]map[int]float64)
+	for rows.Next() {
+		var userID, targetID int
+		var affinity float64
+		if err := rows.Scan(&userID, &targetID, &affinity); err != nil {
+			return err
+		}
+		if affinities[userID] == nil {
+			affinities[userID] = make(map[int]float64)
+		}
+		affinities[userID][targetID] = affinity
+	}
+	if err := rows.Err(); err != nil {
+		return err
+	}
+	for i := range users {
+		users[i].Affinities = affinities[users[i].UserID]
+	}
+	return nil
+}
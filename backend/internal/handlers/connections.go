@@ -25,12 +25,26 @@ func ConnectHandler(w http.ResponseWriter, r *http.Request) {
 
 	var connectRequest struct {
 		TargetUserID int `json:"targetUserId"`
+		// puts the requester at the top of the target's lists; limited per day
+		SuperLike bool `json:"superLike"`
 	}
 
 	if err := json.NewDecoder(r.Body).Decode(&connectRequest); err != nil {
 		http.Error(w, "Invalid request payload", http.StatusBadRequest)
 		return
 	}
+	if connectRequest.TargetUserID == userID {
+		http.Error(w, "Cannot connect to yourself", http.StatusBadRequest)
+		return
+	}
+	// blocks are not revealed to the other side
+	if blocked, err := isBlocked(userID, connectRequest.TargetUserID); err != nil || blocked {
+		if err != nil {
+			log.Printf("Database error while checking blocks: %v\n", err)
+		}
+		http.Error(w, "User not found", http.StatusNotFound)
+		return
+	}
 
 	// check if the target user has already liked the current user
 	var existingStatus string
@@ -52,21 +66,35 @@ func ConnectHandler(w http.ResponseWriter, r *http.Request) {
 			http.Error(w, "Failed to accept connection", http.StatusInternalServerError)
 			return
 		}
+		notifyConnection(connectRequest.TargetUserID, userID, "connection_accepted", "Your connection request was accepted.")
 		json.NewEncoder(w).Encode(map[string]string{"message": "Connection accepted!"})
 		return
 	}
 
-	// insert a new pending connection
-	_, err = db.Pool.Exec(context.Background(), `
-        INSERT INTO connections (user_id, connected_user_id, status)
-        VALUES ($1, $2, 'pending')
-        ON CONFLICT (user_id, connected_user_id) DO NOTHING
-    `, userID, connectRequest.TargetUserID)
+	// insert a new pending connection; answering a request above does not count towards the quotas
+	result, err := sendLike(userID, connectRequest.TargetUserID, connectRequest.SuperLike)
 	if err != nil {
 		log.Printf("Database error while creating connection: %v\n", err)
 		http.Error(w, "Failed to process connection request", http.StatusInternalServerError)
 		return
 	}
+	switch result {
+	case likeQuotaExceeded:
+		http.Error(w, "Daily like limit reached", http.StatusTooManyRequests)
+		return
+	case superLikeQuotaExceeded:
+		http.Error(w, "Daily super like limit reached", http.StatusTooManyRequests)
+		return
+	case likeAlreadySent:
+		// a rejection is not revealed to the requester
+		json.NewEncoder(w).Encode(map[string]string{"message": "Connection request already sent"})
+		return
+	}
+	if connectRequest.SuperLike {
+		notifyConnection(connectRequest.TargetUserID, userID, "connection_request", "Someone super liked you.")
+	} else {
+		notifyConnection(connectRequest.TargetUserID, userID, "connection_request", "You have a new connection request.")
+	}
 
 	w.WriteHeader(http.StatusOK)
 	json.NewEncoder(w).Encode(map[string]string{"message": "Connection request sent successfully"})
@@ -79,10 +107,15 @@ func FetchIncomingRequestsHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	// the same requests as the inbox: none from suspended, banned or hidden users
 	rows, err := db.Pool.Query(context.Background(), `
-        SELECT user_id
-        FROM connections
-        WHERE connected_user_id = $1 AND status = 'pending'
+        SELECT c.user_id
+        FROM connections c
+        JOIN users u ON u.id = c.user_id
+        WHERE c.connected_user_id = $1 AND c.status = 'pending'
+          AND `+visibleCandidateCondition("u")+`
+        -- super likes first
+        ORDER BY c.super_like DESC, c.created_at DESC
     `, userID)
 	if err != nil {
 		http.Error(w, "Error fetching connection requests", http.StatusInternalServerError)
@@ -134,7 +167,7 @@ func RespondToConnectionRequestHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	_, err = db.Pool.Exec(context.Background(), `
+	tag, err := db.Pool.Exec(context.Background(), `
         UPDATE connections
         SET status = $1
         WHERE user_id = $2 AND connected_user_id = $3 AND status = 'pending'
@@ -143,6 +176,10 @@ func RespondToConnectionRequestHandler(w http.ResponseWriter, r *http.Request) {
 		http.Error(w, "Error updating connection status", http.StatusInternalServerError)
 		return
 	}
+	if newStatus == "accepted" && tag.RowsAffected() > 0 {
+		accepterID, _ := strconv.Atoi(userID)
+		notifyConnection(request.RequesterID, accepterID, "connection_accepted", "Your connection request was accepted.")
+	}
 
 	w.WriteHeader(http.StatusOK)
 	json.NewEncoder(w).Encode(map[string]string{"message": "Connection updated successfully"})
@@ -228,3 +265,10 @@ func disconnectHandler(w http.ResponseWriter, r *http.Request) {
 	w.WriteHeader(http.StatusOK)
 	json.NewEncoder(w).Encode(map[string]string{"message": "Disconnected successfully"})
 }
+
+// tells the recipient what the actor did; nothing is stored between blocked users
+func notifyConnection(recipientID, actorID int, notifType, message string) {
+	if err := CreateNotification(recipientID, actorID, notifType, message); err != nil {
+		log.Printf("Error creating %s notification for %d: %v\n", notifType, recipientID, err)
+	}
+}
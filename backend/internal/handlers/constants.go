@@ -10,3 +10,7 @@ var cityOptionsMap = map[string][]string{
 	"Germany": {"Berlin", "Hamburg", "Munich", "Frankfurt"},
 	"Estonia": {"Tallinn", "Tartu", "Narva", "Pärnu"},
 }
+
+var interestsList = []string{"Movies", "Music", "Sports", "Coding", "Nature", "Pets", "Art", "Theatre"}
+var hobbiesList = []string{"Reading", "Gaming", "Cooking", "Art", "Sports", "Music", "Travel", "Photography"}
+var genders = []string{"male", "female", "other"}
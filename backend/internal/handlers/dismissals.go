@@ -0,0 +1,286 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+
+	"matchme-backend/internal/db"
+	"matchme-backend/internal/text"
+	"matchme-backend/internal/utils"
+)
+
+const (
+	// how long after dismissing someone the dismissal can be undone
+	undoDismissWindow = 5 * time.Minute
+	maxDismissDays    = 365
+)
+
+// bios at least this similar (cosine of their TF-IDF vectors) count as the
+// same bio, so fixing a typo does not resurface anyone
+const sameBioSimilarity = 0.8
+
+// SQL for the columns of dismissed_recommendations that record the dismissed
+// user's profile, and the matching values from a users row aliased u
+const (
+	dismissalSnapshotColumns = `snapshot_about, snapshot_hobbies, snapshot_interests, snapshot_photo, snapshot_city`
+	dismissalSnapshotValues  = `u.about, COALESCE(u.hobbies, '[]'::jsonb), COALESCE(u.interests, '[]'::jsonb),
+               u.profile_picture_url, u.city`
+)
+
+// selects the users the viewer ($1) dismissed and who are still hidden: the
+// dismissal has not expired, or it has but their profile has not changed
+// substantially since
+const activeDismissalsQuery = `
+    SELECT dismissed_user_id
+    FROM dismissed_recommendations
+    WHERE user_id = $1
+      AND (expires_at IS NULL OR expires_at > NOW() OR NOT profile_changed)`
+
+// the parts of a profile whose change lets an expired dismissal resurface the user
+type dismissalProfile struct {
+	About     *string
+	Hobbies   *[]string
+	Interests *[]string
+	Photo     *string
+	City      *string
+}
+
+// recomputes, for every dismissal of the user, whether their profile changed
+// substantially since it was made: other hobbies or interests, another city
+// or photo, or a bio that is no longer similar
+func refreshDismissalChanges(userID int) error {
+	ctx := context.Background()
+	var current dismissalProfile
+	err := db.Pool.QueryRow(ctx, `
+        SELECT about, hobbies, interests, profile_picture_url, city FROM users WHERE id = $1
+    `, userID).Scan(&current.About, &current.Hobbies, &current.Interests, &current.Photo, &current.City)
+	if err != nil {
+		return err
+	}
+
+	// dismissals from before snapshots were kept have none and stay changed
+	rows, err := db.Pool.Query(ctx, `
+        SELECT id, `+dismissalSnapshotColumns+`
+        FROM dismissed_recommendations
+        WHERE dismissed_user_id = $1 AND snapshot_hobbies IS NOT NULL
+    `, userID)
+	if err != nil {
+		return err
+	}
+	var ids []int
+	var snapshots []dismissalProfile
+	for rows.Next() {
+		var id int
+		var s dismissalProfile
+		if err := rows.Scan(&id, &s.About, &s.Hobbies, &s.Interests, &s.Photo, &s.City); err != nil {
+			rows.Close()
+			return err
+		}
+		ids = append(ids, id)
+		snapshots = append(snapshots, s)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil || len(ids) == 0 {
+		return err
+	}
+
+	bios := []string{derefString(current.About)}
+	for _, s := range snapshots {
+		bios = append(bios, derefString(s.About))
+	}
+	vectors, err := bioTextVectors(bios)
+	if err != nil {
+		return err
+	}
+
+	batch := &pgx.Batch{}
+	for i, s := range snapshots {
+		changed := !sameItemSet(s.Hobbies, current.Hobbies) ||
+			!sameItemSet(s.Interests, current.Interests) ||
+			derefString(s.City) != derefString(current.City) ||
+			derefString(s.Photo) != derefString(current.Photo) ||
+			!sameBio(vectors[i+1], vectors[0])
+		batch.Queue(`UPDATE dismissed_recommendations SET profile_changed = $1 WHERE id = $2`, changed, ids[i])
+	}
+	return db.Pool.SendBatch(ctx, batch).Close()
+}
+
+// two bios are the same when both are empty or their vectors are similar enough
+func sameBio(a, b map[string]float64) bool {
+	if len(a) == 0 || len(b) == 0 {
+		return len(a) == len(b)
+	}
+	return text.Cosine(a, b) >= sameBioSimilarity
+}
+
+// whether both lists hold the same items, ignoring order and case
+func sameItemSet(a, b *[]string) bool {
+	var as, bs []string
+	if a != nil {
+		as = *a
+	}
+	if b != nil {
+		bs = *b
+	}
+	for _, item := range as {
+		if !containsFold(bs, item) {
+			return false
+		}
+	}
+	for _, item := range bs {
+		if !containsFold(as, item) {
+			return false
+		}
+	}
+	return true
+}
+
+func derefString(s *string) string {
+	if s == nil {
+		return ""
+	}
+	return *s
+}
+
+type dismissal struct {
+	DismissedUserID int     `json:"dismissedUserId"`
+	DismissedAt     string  `json:"dismissed_at"`
+	ExpiresAt       *string `json:"expires_at"`
+	// false once the dismissal expired and the user's profile changed
+	Hidden bool `json:"hidden"`
+}
+
+// POST /recommendations/undo restores the most recent dismissal made within undoDismissWindow
+func UndoDismissHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	userIDStr, err := utils.ExtractUserIDFromTokenFromCookie(r)
+	if err != nil {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+	userID, _ := strconv.Atoi(userIDStr)
+
+	var restoredID int
+	err = db.Pool.QueryRow(context.Background(), `
+        DELETE FROM dismissed_recommendations
+        WHERE id = (
+            SELECT id FROM dismissed_recommendations
+            WHERE user_id = $1 AND created_at >= NOW() - make_interval(secs => $2)
+            ORDER BY created_at DESC, id DESC
+            LIMIT 1
+        )
+        RETURNING dismissed_user_id
+    `, userID, undoDismissWindow.Seconds()).Scan(&restoredID)
+	if errors.Is(err, pgx.ErrNoRows) {
+		http.Error(w, "Nothing to undo", http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		log.Printf("Error undoing dismissal: %v\n", err)
+		http.Error(w, "Error undoing dismissal", http.StatusInternalServerError)
+		return
+	}
+	markRecommendationsStale(userID)
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]int{"dismissedUserId": restoredID})
+}
+
+// GET lists the viewer's dismissals, newest first; DELETE restores one
+func DismissedRecommendationsHandler(w http.ResponseWriter, r *http.Request) {
+	userIDStr, err := utils.ExtractUserIDFromTokenFromCookie(r)
+	if err != nil {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+	userID, _ := strconv.Atoi(userIDStr)
+
+	switch r.Method {
+	case http.MethodGet:
+		listDismissals(w, userID)
+	case http.MethodDelete:
+		restoreDismissal(w, r, userID)
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+func listDismissals(w http.ResponseWriter, userID int) {
+	rows, err := db.Pool.Query(context.Background(), `
+        SELECT d.dismissed_user_id,
+               d.created_at::timestamptz,
+               d.expires_at,
+               d.dismissed_user_id IN (`+activeDismissalsQuery+`) AS hidden
+        FROM dismissed_recommendations d
+        WHERE d.user_id = $1
+        ORDER BY d.created_at DESC, d.id DESC
+    `, userID)
+	if err != nil {
+		log.Printf("Error fetching dismissals: %v\n", err)
+		http.Error(w, "Error retrieving dismissals", http.StatusInternalServerError)
+		return
+	}
+	defer rows.Close()
+
+	dismissals := []dismissal{}
+	for rows.Next() {
+		var d dismissal
+		var dismissedAt time.Time
+		var expiresAt *time.Time
+		if err := rows.Scan(&d.DismissedUserID, &dismissedAt, &expiresAt, &d.Hidden); err != nil {
+			log.Printf("Error scanning dismissal: %v\n", err)
+			http.Error(w, "Error retrieving dismissals", http.StatusInternalServerError)
+			return
+		}
+		d.DismissedAt = dismissedAt.UTC().Format(time.RFC3339)
+		if expiresAt != nil {
+			s := expiresAt.UTC().Format(time.RFC3339)
+			d.ExpiresAt = &s
+		}
+		dismissals = append(dismissals, d)
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(dismissals)
+}
+
+func restoreDismissal(w http.ResponseWriter, r *http.Request, userID int) {
+	var body struct {
+		DismissedUserID int `json:"dismissedUserId"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		http.Error(w, "Invalid JSON", http.StatusBadRequest)
+		return
+	}
+
+	tag, err := db.Pool.Exec(context.Background(), `
+        DELETE FROM dismissed_recommendations
+        WHERE user_id = $1 AND dismissed_user_id = $2
+    `, userID, body.DismissedUserID)
+	if err != nil {
+		log.Printf("Error restoring dismissal: %v\n", err)
+		http.Error(w, "Error restoring dismissal", http.StatusInternalServerError)
+		return
+	}
+	if tag.RowsAffected() == 0 {
+		http.Error(w, "Dismissal not found", http.StatusNotFound)
+		return
+	}
+	markRecommendationsStale(userID)
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]string{
+		"message": "Dismissal removed successfully",
+	})
+}
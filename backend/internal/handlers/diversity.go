@@ -0,0 +1,71 @@
+package handlers
+
+import (
+	"context"
+
+	"matchme-backend/internal/db"
+	"matchme-backend/internal/models"
+	"matchme-backend/internal/scoring"
+)
+
+const (
+	// how many of the best stored candidates the re-ranking picks from
+	rerankPoolSize = 50
+	// trade-off between score (1) and variety (0) when re-ranking
+	diversityLambda = 0.7
+)
+
+// reorders the best-first candidates so the first k are not all alike,
+// returning at most k of them
+func diversify(scored []userWithScore, k int) ([]userWithScore, error) {
+	if len(scored) <= 1 {
+		return scored, nil
+	}
+	ids := make([]int, len(scored))
+	for i, sc := range scored {
+		ids[i] = sc.ID
+	}
+	profiles, err := loadRankingProfiles(ids)
+	if err != nil {
+		return nil, err
+	}
+
+	items := make([]scoring.Ranked, len(scored))
+	byID := make(map[int]userWithScore, len(scored))
+	for i, sc := range scored {
+		u := profiles[sc.ID]
+		u.UserID = sc.ID
+		items[i] = scoring.Ranked{User: u, Score: sc.Score}
+		byID[sc.ID] = sc
+	}
+
+	reranked := scoring.DiversityRerank(items, k, diversityLambda)
+	result := make([]userWithScore, len(reranked))
+	for i, it := range reranked {
+		result[i] = byID[it.User.UserID]
+	}
+	return result, nil
+}
+
+// loads just the fields ProfileSimilarity compares
+func loadRankingProfiles(ids []int) (map[int]models.User, error) {
+	rows, err := db.Pool.Query(context.Background(), `
+        SELECT id, hobbies, interests, TO_CHAR(birthdate, 'YYYY-MM-DD')
+        FROM users
+        WHERE id = ANY($1)
+    `, ids)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	profiles := make(map[int]models.User, len(ids))
+	for rows.Next() {
+		var u models.User
+		if err := rows.Scan(&u.UserID, &u.Hobbies, &u.Interests, &u.Birthdate); err != nil {
+			return nil, err
+		}
+		profiles[u.UserID] = u
+	}
+	return profiles, rows.Err()
+}
@@ -0,0 +1,163 @@
+package handlers
+
+import (
+	"context"
+
+	"matchme-backend/internal/db"
+	"matchme-backend/internal/eval"
+	"matchme-backend/internal/models"
+	"matchme-backend/internal/scoring"
+	"matchme-backend/internal/utils"
+)
+
+// loads every user with a complete profile, what they were served and how
+// they reacted to it for offline evaluation of the strategy
+func LoadEvaluationDataset(strategy *scoring.Strategy) (eval.Dataset, error) {
+	ds := eval.Dataset{
+		Likes:     map[int]map[int]bool{},
+		Dismissed: map[int]map[int]bool{},
+		Matches:   map[int]map[int]bool{},
+	}
+	ctx := context.Background()
+
+	rows, err := db.Pool.Query(ctx, `
+        SELECT
+            id, gender, TO_CHAR(birthdate, 'YYYY-MM-DD'), hobbies, interests, country, city,
+            looking_for_gender, looking_for_min_age, looking_for_max_age,
+            preferred_hobbies, preferred_interests, last_active_at, profile_completed_at,
+            gender_strictness, age_strictness, age_tolerance,
+            min_shared_hobbies, min_shared_interests, shared_strictness
+        FROM users
+        WHERE `+utils.CompleteProfileCondition("")+`
+        ORDER BY id
+    `)
+	if err != nil {
+		return ds, err
+	}
+	for rows.Next() {
+		var u models.User
+		err := rows.Scan(
+			&u.UserID, &u.Gender, &u.Birthdate, &u.Hobbies, &u.Interests, &u.Country, &u.City,
+			&u.LookingForGender, &u.LookingForMinAge, &u.LookingForMaxAge,
+			&u.PreferredHobbies, &u.PreferredInterests, &u.LastActiveAt, &u.ProfileCompletedAt,
+			&u.GenderStrictness, &u.AgeStrictness, &u.AgeTolerance,
+			&u.MinSharedHobbies, &u.MinSharedInterests, &u.SharedStrictness,
+		)
+		if err != nil {
+			rows.Close()
+			return ds, err
+		}
+		ds.Users = append(ds.Users, u)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return ds, err
+	}
+	if err := attachAttributes(ds.Users); err != nil {
+		return ds, err
+	}
+	if err := attachBioVectors(ds.Users); err != nil {
+		return ds, err
+	}
+	if err := attachAnswers(ds.Users); err != nil {
+		return ds, err
+	}
+
+	if err := attachAllAffinities(ds.Users, strategy); err != nil {
+		return ds, err
+	}
+
+	if ds.Served, err = loadServedLists(); err != nil {
+		return ds, err
+	}
+	if len(ds.Served) == 0 {
+		// nothing was served yet: fall back to ranking everyone
+		ds.Served = nil
+	}
+
+	// with served lists only reactions to a served candidate made after it
+	// was shown count; a match counts however it came about
+	rows, err = db.Pool.Query(ctx, `
+        SELECT c.user_id, c.connected_user_id, c.status,
+               COALESCE(c.created_at::timestamptz >= s.served_at, FALSE)
+        FROM connections c
+        LEFT JOIN served_recommendations s
+            ON s.user_id = c.user_id AND s.recommended_user_id = c.connected_user_id
+    `)
+	if err != nil {
+		return ds, err
+	}
+	for rows.Next() {
+		var from, to int
+		var status string
+		var afterServed bool
+		if err := rows.Scan(&from, &to, &status, &afterServed); err != nil {
+			rows.Close()
+			return ds, err
+		}
+		if ds.Served == nil || afterServed {
+			addPair(ds.Likes, from, to)
+		}
+		if status == "accepted" {
+			addPair(ds.Matches, from, to)
+			addPair(ds.Matches, to, from)
+		}
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return ds, err
+	}
+
+	rows, err = db.Pool.Query(ctx, `
+        SELECT d.user_id, d.dismissed_user_id,
+               COALESCE(d.created_at::timestamptz >= s.served_at, FALSE)
+        FROM dismissed_recommendations d
+        LEFT JOIN served_recommendations s
+            ON s.user_id = d.user_id AND s.recommended_user_id = d.dismissed_user_id
+    `)
+	if err != nil {
+		return ds, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var from, to int
+		var afterServed bool
+		if err := rows.Scan(&from, &to, &afterServed); err != nil {
+			return ds, err
+		}
+		if ds.Served == nil || afterServed {
+			addPair(ds.Dismissed, from, to)
+		}
+	}
+	return ds, rows.Err()
+}
+
+// every viewer's served candidates, in the order they were first shown
+func loadServedLists() (map[int][]int, error) {
+	rows, err := db.Pool.Query(context.Background(), `
+        SELECT user_id, recommended_user_id
+        FROM served_recommendations
+        ORDER BY user_id, served_at, recommended_user_id
+    `)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	served := make(map[int][]int)
+	for rows.Next() {
+		var viewerID, id int
+		if err := rows.Scan(&viewerID, &id); err != nil {
+			return nil, err
+		}
+		served[viewerID] = append(served[viewerID], id)
+	}
+	return served, rows.Err()
+}
+
+func addPair(pairs map[int]map[int]bool, from, to int) {
+	if pairs[from] == nil {
+		pairs[from] = map[int]bool{}
+	}
+	pairs[from][to] = true
+}
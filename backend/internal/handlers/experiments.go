@@ -0,0 +1,102 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"log"
+	"net/http"
+
+	"matchme-backend/internal/db"
+	"matchme-backend/internal/scoring"
+)
+
+// outcomes of the recommendations shown in one variant of an experiment
+type variantReport struct {
+	Variant string `json:"variant"`
+	Users   int    `json:"users"`
+	Shown   int    `json:"shown"`
+	// the viewer sent the recommended user a connection request
+	Connects int `json:"connects"`
+	// ...which was accepted
+	Accepted int `json:"accepted"`
+	// ...and the pair exchanged at least one message
+	Chatted     int     `json:"chatted"`
+	ConnectRate float64 `json:"connect_rate"` // connects / shown
+	AcceptRate  float64 `json:"accept_rate"`  // accepted / connects
+	ChatRate    float64 `json:"chat_rate"`    // chatted / accepted
+}
+
+// GET /admin/experiments/report?experiment=name compares the variants of an
+// experiment (the running one by default) over everything ever served in it
+func ExperimentReportHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Header.Get("X-Admin-Secret") != adminSecret {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	name := r.URL.Query().Get("experiment")
+	if name == "" {
+		if e := scoring.CurrentExperiment(); e != nil {
+			name = e.Name
+		}
+	}
+	if name == "" {
+		http.Error(w, "No experiment is running", http.StatusNotFound)
+		return
+	}
+
+	rows, err := db.Pool.Query(context.Background(), `
+        SELECT r.variant,
+               COUNT(DISTINCT r.user_id),
+               COUNT(*),
+               COUNT(c.id),
+               COUNT(c.id) FILTER (WHERE c.status = 'accepted'),
+               COUNT(c.id) FILTER (WHERE c.status = 'accepted' AND EXISTS (
+                   SELECT 1 FROM chats ch
+                   WHERE (ch.sender_id = r.user_id AND ch.receiver_id = r.recommended_user_id)
+                      OR (ch.sender_id = r.recommended_user_id AND ch.receiver_id = r.user_id)
+               ))
+        FROM served_recommendations r
+        LEFT JOIN connections c ON c.user_id = r.user_id AND c.connected_user_id = r.recommended_user_id
+        WHERE r.experiment = $1
+        GROUP BY r.variant
+        ORDER BY r.variant
+    `, name)
+	if err != nil {
+		log.Printf("Error building experiment report: %v\n", err)
+		http.Error(w, "Error building experiment report", http.StatusInternalServerError)
+		return
+	}
+	defer rows.Close()
+
+	variants := []variantReport{}
+	for rows.Next() {
+		var v variantReport
+		if err := rows.Scan(&v.Variant, &v.Users, &v.Shown, &v.Connects, &v.Accepted, &v.Chatted); err != nil {
+			log.Printf("Error scanning experiment report: %v\n", err)
+			http.Error(w, "Error building experiment report", http.StatusInternalServerError)
+			return
+		}
+		v.ConnectRate = rate(v.Connects, v.Shown)
+		v.AcceptRate = rate(v.Accepted, v.Connects)
+		v.ChatRate = rate(v.Chatted, v.Accepted)
+		variants = append(variants, v)
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"experiment": name,
+		"variants":   variants,
+	})
+}
+
+func rate(n, of int) float64 {
+	if of == 0 {
+		return 0
+	}
+	return float64(n) / float64(of)
+}
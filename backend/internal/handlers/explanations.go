@@ -0,0 +1,244 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"log"
+	"net/http"
+	"regexp"
+	"strconv"
+	"time"
+
+	"matchme-backend/internal/db"
+	"matchme-backend/internal/models"
+	"matchme-backend/internal/scoring"
+	"matchme-backend/internal/utils"
+)
+
+// why a candidate is recommended to the viewer
+// only data the viewer could already see on the candidate's profile is included
+type recommendationExplanation struct {
+	ID                int                     `json:"id"`
+	Score             float64                 `json:"score"`
+	StoredScore       *float64                `json:"stored_score,omitempty"`
+	SharedHobbies     []scoring.SharedItem    `json:"shared_hobbies"`
+	SharedInterests   []scoring.SharedItem    `json:"shared_interests"`
+	SameCity          *bool                   `json:"same_city,omitempty"`
+	AgeInRange        bool                    `json:"age_in_range"`
+	GenderMatch       bool                    `json:"gender_match"`
+	MatchedAttributes []string                `json:"matched_attributes"`
+	Contributions     []explainedContribution `json:"contributions"`
+	// whether the viewer fits the candidate's preferences, for reciprocal
+	// strategies; the details would reveal the candidate's private settings
+	FitsTheirPreferences *bool `json:"fits_their_preferences,omitempty"`
+}
+
+// a signal's part in the score as shown to the viewer
+type explainedContribution struct {
+	Signal   string  `json:"signal"`
+	Weight   float64 `json:"weight"`
+	Points   float64 `json:"points"`
+	Excluded bool    `json:"excluded,omitempty"`
+	// only for signals the viewer can work out from the shared items anyway
+	Value *float64 `json:"value,omitempty"`
+}
+
+// signals included in explanations; activity and newcomer are left out as
+// they give away when the candidate was last online or signed up
+var explainedSignals = map[string]bool{
+	"location":      true,
+	"distance":      true,
+	"age":           true,
+	"gender":        true,
+	"hobbies":       true,
+	"interests":     true,
+	"attributes":    true,
+	"bio":           true,
+	"collaborative": true,
+	"compatibility": true,
+}
+
+// signals whose raw value is shown
+var explainedValues = map[string]bool{"hobbies": true, "interests": true}
+
+func explainContributions(contribs []scoring.Contribution) []explainedContribution {
+	explained := []explainedContribution{}
+	for _, c := range contribs {
+		if !explainedSignals[c.Signal] {
+			continue
+		}
+		ec := explainedContribution{Signal: c.Signal, Weight: c.Weight, Points: c.Points, Excluded: c.Excluded}
+		if explainedValues[c.Signal] {
+			value := c.Value
+			ec.Value = &value
+		}
+		explained = append(explained, ec)
+	}
+	return explained
+}
+
+// the viewer passes every check the candidate's preferences make, fully
+// meeting their age range and gender
+func fitsPreferences(reverse []scoring.Contribution) bool {
+	for _, c := range reverse {
+		if c.Excluded {
+			return false
+		}
+		switch c.Signal {
+		case "age":
+			if c.Value != 1 {
+				return false
+			}
+		case "gender":
+			if c.Value < 0 {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// breaks down the viewer's strategy's score for one candidate
+func explainRecommendation(viewer, target models.User, rel viewerRelation) recommendationExplanation {
+	strategy, _, _ := scoring.StrategyFor(viewer.UserID)
+	e := strategy.Explain(viewer, target)
+	cityVisible := canSeeField(target.CityVisibility, visibilityEveryone, rel)
+
+	exp := recommendationExplanation{
+		ID:                target.UserID,
+		Score:             e.Score,
+		SharedHobbies:     scoring.SharedItems(viewer.Hobbies, target.Hobbies, viewer.PreferredHobbies),
+		SharedInterests:   scoring.SharedItems(viewer.Interests, target.Interests, viewer.PreferredInterests),
+		MatchedAttributes: []string{},
+	}
+	if e.ReverseContributions != nil {
+		fits := fitsPreferences(e.ReverseContributions)
+		exp.FitsTheirPreferences = &fits
+	}
+
+	for _, c := range e.Contributions {
+		switch c.Signal {
+		case "age":
+			// values below 1 come from a tolerance or a soft preference
+			exp.AgeInRange = !c.Excluded && c.Value == 1
+		case "gender":
+			exp.GenderMatch = !c.Excluded && c.Value >= 0
+		case "location":
+			if cityVisible {
+				same := c.Value == 1
+				exp.SameCity = &same
+			}
+		}
+	}
+	for name, filter := range viewer.AttributeFilters {
+		if filter != nil && scoring.AttributeFilterMatches(filter, target.Attributes[name]) {
+			exp.MatchedAttributes = append(exp.MatchedAttributes, name)
+		}
+	}
+
+	contribs := e.Contributions
+	if !cityVisible {
+		// location and distance points would reveal whether the city is the viewer's
+		contribs = mergeLocationContributions(contribs)
+	}
+	exp.Contributions = explainContributions(contribs)
+	return exp
+}
+
+// folds the location and distance contributions into a single one without raw values
+func mergeLocationContributions(contribs []scoring.Contribution) []scoring.Contribution {
+	if contribs == nil {
+		return nil
+	}
+	merged := scoring.Contribution{Signal: "location"}
+	found := false
+	result := make([]scoring.Contribution, 0, len(contribs))
+	for _, c := range contribs {
+		if c.Signal != "location" && c.Signal != "distance" {
+			result = append(result, c)
+			continue
+		}
+		found = true
+		merged.Points += c.Points
+		merged.Weight += c.Weight
+		merged.Excluded = merged.Excluded || c.Excluded
+	}
+	if found {
+		result = append(result, merged)
+	}
+	return result
+}
+
+// /recommendations/{id}/why
+func RecommendationWhyHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	pathRegex := regexp.MustCompile(`^/recommendations/(\d+)/why$`)
+	parts := pathRegex.FindStringSubmatch(r.URL.Path)
+	if len(parts) == 0 {
+		http.NotFound(w, r)
+		return
+	}
+	targetID, err := strconv.Atoi(parts[1])
+	if err != nil {
+		http.Error(w, "Invalid user ID", http.StatusBadRequest)
+		return
+	}
+
+	viewerIDStr, err := utils.ExtractUserIDFromTokenFromCookie(r)
+	if err != nil {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+	viewerID, _ := strconv.Atoi(viewerIDStr)
+
+	allowed, err := IsUserAllowedToViewProfile(viewerID, targetID)
+	if err != nil || !allowed || viewerID == targetID {
+		http.NotFound(w, r)
+		return
+	}
+
+	viewer, err := getUserByID(viewerID)
+	if err != nil {
+		log.Printf("Error fetching viewer user: %v\n", err)
+		http.Error(w, "Error retrieving viewer data", http.StatusInternalServerError)
+		return
+	}
+	target, err := getUserByID(targetID)
+	if err != nil {
+		http.NotFound(w, r)
+		return
+	}
+	rel, err := getViewerRelation(viewerID, targetID)
+	if err != nil {
+		log.Printf("Error resolving viewer relation: %v\n", err)
+		http.Error(w, "Error retrieving explanation", http.StatusInternalServerError)
+		return
+	}
+
+	now := time.Now()
+	viewer.LastActiveAt = &now
+	targets := []models.User{target}
+	strategy, _, _ := scoring.StrategyFor(viewerID)
+	if err := prepareScoring(&viewer, targets, strategy); err != nil {
+		log.Printf("Error loading scoring data: %v\n", err)
+	}
+	exp := explainRecommendation(viewer, targets[0], rel)
+
+	var stored float64
+	err = db.Pool.QueryRow(context.Background(), `
+        SELECT score FROM recommendations
+        WHERE user_id = $1 AND recommended_user_id = $2
+        ORDER BY created_at DESC
+        LIMIT 1
+    `, viewerID, targetID).Scan(&stored)
+	if err == nil {
+		exp.StoredScore = &stored
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(exp)
+}
@@ -0,0 +1,168 @@
+package handlers
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"matchme-backend/internal/scoring"
+	"matchme-backend/internal/utils"
+)
+
+const (
+	defaultFeedPageSize = 10
+	maxFeedPageSize     = 50
+	// people shown within this window are not shown again when a new feed session starts
+	feedSessionWindow = 30 * time.Minute
+)
+
+// position in the feed; encoded as an opaque string for clients
+type feedCursor struct {
+	Score        string `json:"s"`
+	ID           int    `json:"id"`
+	SessionStart int64  `json:"t"`
+}
+
+func encodeFeedCursor(c feedCursor) string {
+	data, _ := json.Marshal(c)
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+func decodeFeedCursor(s string) (feedCursor, error) {
+	var c feedCursor
+	data, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return c, err
+	}
+	err = json.Unmarshal(data, &c)
+	return c, err
+}
+
+type feedItem struct {
+	ID    int     `json:"id"`
+	Score float64 `json:"score"`
+}
+
+// GET /recommendations/feed?limit=N&cursor=...
+// pages through all precomputed recommendations ordered by (score, id);
+// a request without a cursor starts a new session that skips people shown
+// during the last feedSessionWindow and opens with the people who super
+// liked the viewer
+func RecommendationFeedHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	userIDStr, err := utils.ExtractUserIDFromTokenFromCookie(r)
+	if err != nil {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+	userID, _ := strconv.Atoi(userIDStr)
+
+	limit := defaultFeedPageSize
+	if limitStr := r.URL.Query().Get("limit"); limitStr != "" {
+		limit, err = strconv.Atoi(limitStr)
+		if err != nil || limit < 1 || limit > maxFeedPageSize {
+			http.Error(w, "Invalid limit", http.StatusBadRequest)
+			return
+		}
+	}
+
+	cursor := feedCursor{SessionStart: time.Now().Unix()}
+	query := storedRecommendationQuery{Limit: limit, ExposureCap: dailyExposureCap}
+	cursorStr := r.URL.Query().Get("cursor")
+	if cursorStr != "" {
+		// an empty score continues the session from the top of the list
+		cursor, err = decodeFeedCursor(cursorStr)
+		if err != nil || cursor.SessionStart == 0 || (cursor.Score == "" && cursor.ID != 0) {
+			http.Error(w, "Invalid cursor", http.StatusBadRequest)
+			return
+		}
+		if cursor.Score != "" {
+			query.AfterScore = &cursor.Score
+			query.AfterID = cursor.ID
+		}
+	}
+	notServedSince := time.Unix(cursor.SessionStart, 0).Add(-feedSessionWindow)
+	query.NotServedSince = &notServedSince
+
+	page, computed, err := loadStoredRecommendations(userID, query)
+	if err == nil && !computed {
+		if err = RecomputeRecommendations(userID); err == nil {
+			page, _, err = loadStoredRecommendations(userID, query)
+		}
+	}
+	if err != nil {
+		log.Printf("Error loading recommendation feed: %v\n", err)
+		http.Error(w, "Error retrieving matches", http.StatusInternalServerError)
+		return
+	}
+
+	// people who super liked the viewer open the session; being served now,
+	// they are skipped when the rest of the list comes up later on
+	var senders []int
+	if cursorStr == "" {
+		strategy, _, _ := scoring.StrategyFor(userID)
+		if senders, err = pendingSuperLikes(userID, strategy); err != nil {
+			log.Printf("Error loading super likes: %v\n", err)
+			http.Error(w, "Error retrieving matches", http.StatusInternalServerError)
+			return
+		}
+		if len(senders) > limit {
+			senders = senders[:limit]
+		}
+	}
+	scores := make(map[int]float64, len(page))
+	for _, rec := range page {
+		scores[rec.ID] = rec.Score
+	}
+	items := make([]feedItem, 0, limit)
+	ids := make([]int, 0, limit)
+	seen := make(map[int]bool)
+	for _, id := range senders {
+		items = append(items, feedItem{ID: id, Score: scores[id]})
+		ids = append(ids, id)
+		seen[id] = true
+	}
+	// the cursor stays at the last stored recommendation actually shown
+	next := feedCursor{SessionStart: cursor.SessionStart}
+	shown := 0
+	for _, rec := range page {
+		if len(items) == limit {
+			break
+		}
+		shown++
+		next.Score, next.ID = rec.ScoreText, rec.ID
+		if seen[rec.ID] {
+			continue
+		}
+		items = append(items, feedItem{ID: rec.ID, Score: rec.Score})
+		ids = append(ids, rec.ID)
+	}
+	if err := markRecommendationsServed(userID, ids); err != nil {
+		log.Printf("Error marking recommendations served: %v\n", err)
+	}
+	if err := recordExposures(userID, ids); err != nil {
+		log.Printf("Error recording exposures: %v\n", err)
+	}
+
+	var nextCursor *string
+	if len(page) == limit || shown < len(page) {
+		c := encodeFeedCursor(next)
+		nextCursor = &c
+	} else {
+		// reached the end of the precomputed pool: let the worker refill it
+		requestRecommendationRefill(userID)
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"items":       items,
+		"next_cursor": nextCursor,
+	})
+}
@@ -0,0 +1,235 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"log"
+	"math"
+	"net/http"
+	"sort"
+	"strconv"
+	"time"
+
+	"matchme-backend/internal/db"
+	"matchme-backend/internal/models"
+	"matchme-backend/internal/scoring"
+	"matchme-backend/internal/utils"
+)
+
+const (
+	defaultInboxPageSize = 20
+	maxInboxPageSize     = 50
+
+	inboxSortRecent        = "recent"
+	inboxSortCompatibility = "compatibility"
+)
+
+// someone who sent the viewer a connection request, as shown in the inbox;
+// name and photo follow the requester's privacy settings
+type inboxCard struct {
+	ID              int      `json:"id"`
+	Name            string   `json:"name"`
+	Photo           *string  `json:"photo"`
+	Age             *int     `json:"age"`
+	SharedInterests []string `json:"shared_interests"`
+	LikedAt         string   `json:"liked_at"`
+	SuperLike       bool     `json:"super_like"`
+	// questionnaire match in percent, nil without common questions
+	Compatibility *int `json:"compatibility"`
+
+	likedAt time.Time
+}
+
+// GET /connections/inbox?sort=recent|compatibility&limit=N&offset=M
+// lists pending connection requests to the viewer as cards, super likes
+// first within equal rank
+func InboxHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	userIDStr, err := utils.ExtractUserIDFromTokenFromCookie(r)
+	if err != nil {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+	userID, _ := strconv.Atoi(userIDStr)
+
+	query := r.URL.Query()
+	sortBy := query.Get("sort")
+	if sortBy == "" {
+		sortBy = inboxSortRecent
+	}
+	if sortBy != inboxSortRecent && sortBy != inboxSortCompatibility {
+		http.Error(w, "Invalid sort", http.StatusBadRequest)
+		return
+	}
+	limit := defaultInboxPageSize
+	if limitStr := query.Get("limit"); limitStr != "" {
+		limit, err = strconv.Atoi(limitStr)
+		if err != nil || limit < 1 || limit > maxInboxPageSize {
+			http.Error(w, "Invalid limit", http.StatusBadRequest)
+			return
+		}
+	}
+	offset := 0
+	if offsetStr := query.Get("offset"); offsetStr != "" {
+		offset, err = strconv.Atoi(offsetStr)
+		if err != nil || offset < 0 {
+			http.Error(w, "Invalid offset", http.StatusBadRequest)
+			return
+		}
+	}
+
+	page, total, err := loadInbox(userID, sortBy, limit, offset)
+	if err != nil {
+		log.Printf("Error loading inbox: %v\n", err)
+		http.Error(w, "Error fetching connection requests", http.StatusInternalServerError)
+		return
+	}
+	var next *int
+	if offset+len(page) < total {
+		n := offset + len(page)
+		next = &n
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"items":       page,
+		"total":       total,
+		"next_offset": next,
+	})
+}
+
+// pending requests to the viewer in $1 from visible accounts
+var inboxRequestsSQL = `
+        FROM connections c
+        JOIN users u ON u.id = c.user_id
+        WHERE c.connected_user_id = $1
+          AND c.status = 'pending'
+          AND ` + visibleCandidateCondition("u")
+
+// builds the cards of one inbox page and counts all pending requests to the
+// viewer from visible accounts; the recent order is paginated in SQL, while
+// compatibility needs every card scored before the page can be cut out
+func loadInbox(viewerID int, sortBy string, limit, offset int) ([]inboxCard, int, error) {
+	ctx := context.Background()
+	var total int
+	if err := db.Pool.QueryRow(ctx, `SELECT COUNT(*) `+inboxRequestsSQL, viewerID).Scan(&total); err != nil {
+		return nil, 0, err
+	}
+	if sortBy == inboxSortRecent && offset >= total {
+		return []inboxCard{}, total, nil
+	}
+
+	viewer, err := getUserByID(viewerID)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	query := `
+        SELECT u.id, u.fname, u.surname, u.surname_visibility, u.profile_picture_url, u.photo_visibility,
+               TO_CHAR(u.birthdate, 'YYYY-MM-DD'), u.interests,
+               c.created_at::timestamptz, c.super_like
+        ` + inboxRequestsSQL
+	args := []any{viewerID}
+	if sortBy == inboxSortRecent {
+		query += `
+        ORDER BY c.created_at DESC, u.id
+        LIMIT $2 OFFSET $3`
+		args = append(args, limit, offset)
+	}
+	rows, err := db.Pool.Query(ctx, query, args...)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer rows.Close()
+
+	cards := []inboxCard{}
+	var ids []int
+	for rows.Next() {
+		var u models.User
+		var c inboxCard
+		if err := rows.Scan(&u.UserID, &u.Fname, &u.Surname, &u.SurnameVisibility, &u.Picture, &u.PhotoVisibility,
+			&u.Birthdate, &u.Interests, &c.likedAt, &c.SuperLike); err != nil {
+			return nil, 0, err
+		}
+		// pending requesters are eligible to each other, like in /users/{id}
+		minimal := serializeUser(u, relationEligible, viewMinimal)
+		c.ID = u.UserID
+		c.Name = minimal["name"].(string)
+		c.Photo, _ = minimal["photo"].(*string)
+		if u.Birthdate != nil {
+			age := utils.CalcAge(*u.Birthdate)
+			c.Age = &age
+		}
+		c.SharedInterests = sharedItemNames(viewer.Interests, u.Interests)
+		c.LikedAt = c.likedAt.UTC().Format(time.RFC3339)
+		cards = append(cards, c)
+		ids = append(ids, u.UserID)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, 0, err
+	}
+
+	answers, err := loadAnswers(append(ids, viewerID))
+	if err != nil {
+		return nil, 0, err
+	}
+	for i := range cards {
+		match, common := scoring.Compatibility(answers[viewerID], answers[cards[i].ID])
+		if common > 0 {
+			pct := int(math.Round(match * 100))
+			cards[i].Compatibility = &pct
+		}
+	}
+	if sortBy == inboxSortRecent {
+		return cards, total, nil
+	}
+
+	sortInboxByCompatibility(cards)
+	if offset >= len(cards) {
+		return []inboxCard{}, total, nil
+	}
+	return cards[offset:min(offset+limit, len(cards))], total, nil
+}
+
+// the requester's items the viewer also has, in the requester's spelling
+func sharedItemNames(viewerItems, items *[]string) []string {
+	shared := []string{}
+	if viewerItems == nil || items == nil {
+		return shared
+	}
+	for _, item := range *items {
+		if containsFold(*viewerItems, item) {
+			shared = append(shared, item)
+		}
+	}
+	return shared
+}
+
+// orders cards by compatibility (unknown last), super likes first among
+// equals, then newest first like the recent order
+func sortInboxByCompatibility(cards []inboxCard) {
+	sort.SliceStable(cards, func(i, j int) bool {
+		a, b := cards[i], cards[j]
+		ca, cb := -1, -1
+		if a.Compatibility != nil {
+			ca = *a.Compatibility
+		}
+		if b.Compatibility != nil {
+			cb = *b.Compatibility
+		}
+		if ca != cb {
+			return ca > cb
+		}
+		if a.SuperLike != b.SuperLike {
+			return a.SuperLike
+		}
+		if !a.likedAt.Equal(b.likedAt) {
+			return a.likedAt.After(b.likedAt)
+		}
+		return a.ID < b.ID
+	})
+}
@@ -25,6 +25,7 @@ func FetchNotificationsHandler(w http.ResponseWriter, r *http.Request) {
         SELECT id, type, message, read, created_at
         FROM notifications
         WHERE user_id = $1
+          AND (actor_id IS NULL OR actor_id NOT IN (`+blockedUsersQuery+`))
         ORDER BY created_at DESC
     `, userID)
 	if err != nil {
@@ -96,10 +97,17 @@ func MarkNotificationAsReadHandler(w http.ResponseWriter, r *http.Request) {
 	json.NewEncoder(w).Encode(map[string]string{"message": "Notification marked as read"})
 }
 
-func CreateNotification(userID int, notifType, message string) error {
+// notifies userID; actorID is the user the notification is about (0 for
+// none), and nothing is stored when either blocked the other
+func CreateNotification(userID, actorID int, notifType, message string) error {
 	_, err := db.Pool.Exec(context.Background(), `
-        INSERT INTO notifications (user_id, type, message)
-        VALUES ($1, $2, $3)
-    `, userID, notifType, message)
+        INSERT INTO notifications (user_id, actor_id, type, message)
+        SELECT $1, NULLIF($2, 0), $3, $4
+        WHERE NOT EXISTS (
+            SELECT 1 FROM blocks
+            WHERE (blocker_id = $1 AND blocked_id = $2)
+               OR (blocker_id = $2 AND blocked_id = $1)
+        )
+    `, userID, actorID, notifType, message)
 	return err
 }
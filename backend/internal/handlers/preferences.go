@@ -0,0 +1,51 @@
+package handlers
+
+// SQL for the partner preferences that are hard filters, mirroring the
+// gender, age, hobbies and interests signals; conditions apply to a
+// candidate row of users (unqualified) and the viewer ($1)
+
+// how many distinct items the candidate's JSONB list shares with the viewer's
+func sharedItemsSQL(column string) string {
+	return `(SELECT COUNT(DISTINCT LOWER(c.item))
+             FROM jsonb_array_elements_text(COALESCE(` + column + `, '[]'::jsonb)) AS c(item)
+             WHERE LOWER(c.item) IN (
+                 SELECT LOWER(v.item)
+                 FROM users vu, jsonb_array_elements_text(COALESCE(vu.` + column + `, '[]'::jsonb)) AS v(item)
+                 WHERE vu.id = $1))`
+}
+
+// the candidate fits the viewer's hard preferences
+const viewerPreferenceConditions = `
+          AND ((SELECT gender_strictness FROM users WHERE id = $1) = 'soft'
+               OR (SELECT looking_for_gender FROM users WHERE id = $1) = 'any'
+               OR gender = (SELECT looking_for_gender FROM users WHERE id = $1))
+          AND ((SELECT age_strictness FROM users WHERE id = $1) = 'soft'
+               OR DATE_PART('year', AGE(birthdate)) BETWEEN
+                  (SELECT looking_for_min_age - age_tolerance FROM users WHERE id = $1)
+                  AND (SELECT looking_for_max_age + age_tolerance FROM users WHERE id = $1))`
+
+// the viewer fits the candidate's hard preferences
+const candidatePreferenceConditions = `
+          AND (gender_strictness = 'soft'
+               OR looking_for_gender = 'any'
+               OR looking_for_gender = (SELECT gender FROM users WHERE id = $1))
+          AND (age_strictness = 'soft'
+               OR (SELECT DATE_PART('year', AGE(birthdate)) FROM users WHERE id = $1)
+                  BETWEEN looking_for_min_age - age_tolerance AND looking_for_max_age + age_tolerance)`
+
+// returns the conditions for the hard preferences that apply: the viewer's
+// always, the candidate's only for reciprocal strategies
+func preferenceConditions(reciprocal bool) string {
+	hobbies, interests := sharedItemsSQL("hobbies"), sharedItemsSQL("interests")
+	conditions := viewerPreferenceConditions + `
+          AND ((SELECT shared_strictness FROM users WHERE id = $1) = 'soft'
+               OR (` + hobbies + ` >= (SELECT min_shared_hobbies FROM users WHERE id = $1)
+                   AND ` + interests + ` >= (SELECT min_shared_interests FROM users WHERE id = $1)))`
+	if reciprocal {
+		conditions += candidatePreferenceConditions + `
+          AND (shared_strictness = 'soft'
+               OR (` + hobbies + ` >= min_shared_hobbies
+                   AND ` + interests + ` >= min_shared_interests))`
+	}
+	return conditions
+}
@@ -0,0 +1,192 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"strings"
+
+	"matchme-backend/internal/db"
+	"matchme-backend/internal/models"
+	"matchme-backend/internal/utils"
+)
+
+const (
+	visibilityEveryone    = "everyone"
+	visibilityConnections = "connections"
+	visibilityNobody      = "nobody"
+)
+
+var visibilityLevels = []string{visibilityEveryone, visibilityConnections, visibilityNobody}
+
+// how the viewer is related to the profile owner
+type viewerRelation int
+
+const (
+	// recommended or pending: allowed to see the profile at all
+	relationEligible viewerRelation = iota
+	relationConnected
+	relationSelf
+)
+
+// profile views served under /users/{id}
+const (
+	viewMinimal = ""
+	viewProfile = "profile"
+	viewBio     = "bio"
+)
+
+// figures out whether the viewer is the owner, an accepted connection or just eligible
+func getViewerRelation(viewerID, targetID int) (viewerRelation, error) {
+	if viewerID == targetID {
+		return relationSelf, nil
+	}
+	var connected bool
+	err := db.Pool.QueryRow(context.Background(), `
+        SELECT EXISTS (
+            SELECT 1 FROM connections
+            WHERE ((user_id = $1 AND connected_user_id = $2)
+                OR (user_id = $2 AND connected_user_id = $1))
+              AND status = 'accepted'
+        )
+    `, viewerID, targetID).Scan(&connected)
+	if err != nil {
+		return relationEligible, err
+	}
+	if connected {
+		return relationConnected, nil
+	}
+	return relationEligible, nil
+}
+
+// checks a field's visibility setting against the viewer's relation
+// unset settings fall back to the given default
+func canSeeField(setting *string, fallback string, rel viewerRelation) bool {
+	if rel == relationSelf {
+		return true
+	}
+	level := fallback
+	if setting != nil {
+		level = strings.ToLower(*setting)
+	}
+	switch level {
+	case visibilityEveryone:
+		return true
+	case visibilityConnections:
+		return rel == relationConnected
+	default:
+		return false
+	}
+}
+
+// builds the JSON body for one of the /users/{id} views, applying the
+// owner's privacy settings; this is the only place user data is serialized
+// for other users
+func serializeUser(user models.User, rel viewerRelation, view string) map[string]interface{} {
+	showSurname := canSeeField(user.SurnameVisibility, visibilityConnections, rel)
+	showBirthdate := canSeeField(user.BirthdateVisibility, visibilityConnections, rel)
+	showCity := canSeeField(user.CityVisibility, visibilityEveryone, rel)
+	showPhoto := canSeeField(user.PhotoVisibility, visibilityEveryone, rel)
+
+	var surname, birthdate, city, photo *string
+	if showSurname {
+		surname = user.Surname
+	}
+	if showBirthdate {
+		birthdate = user.Birthdate
+	}
+	if showCity {
+		city = user.City
+	}
+	if showPhoto {
+		photo = user.Picture
+	}
+
+	// the age is always shown, even when the exact birthdate is hidden
+	var age *int
+	if user.Birthdate != nil {
+		a := utils.CalcAge(*user.Birthdate)
+		age = &a
+	}
+
+	switch view {
+	case viewProfile:
+		resp := map[string]interface{}{
+			"id":                  user.UserID,
+			"fname":               user.Fname,
+			"surname":             surname,
+			"about":               user.About,
+			"profile_picture_url": photo,
+			"gender":              user.Gender,
+			"birthdate":           birthdate,
+			"age":                 age,
+			"hobbies":             user.Hobbies,
+			"interests":           user.Interests,
+			"country":             user.Country,
+			"city":                city,
+			"looking_for_gender":  user.LookingForGender,
+			"looking_for_min_age": user.LookingForMinAge,
+			"looking_for_max_age": user.LookingForMaxAge,
+			"preferred_hobbies":   user.PreferredHobbies,
+			"preferred_interests": user.PreferredInterests,
+			"attributes":          attributesOrEmpty(user.Attributes),
+			"visiting":            visitingView(user, showCity),
+		}
+		if rel == relationSelf {
+			resp["email"] = user.Email
+			resp["surname_visibility"] = user.SurnameVisibility
+			resp["birthdate_visibility"] = user.BirthdateVisibility
+			resp["city_visibility"] = user.CityVisibility
+			resp["photo_visibility"] = user.PhotoVisibility
+			resp["attribute_filters"] = user.AttributeFilters
+			resp["gender_strictness"] = user.GenderStrictness
+			resp["age_strictness"] = user.AgeStrictness
+			resp["age_tolerance"] = user.AgeTolerance
+			resp["min_shared_hobbies"] = user.MinSharedHobbies
+			resp["min_shared_interests"] = user.MinSharedInterests
+			resp["shared_strictness"] = user.SharedStrictness
+			resp["travel"] = travelPlan(user)
+			resp["timezone"] = user.Timezone
+		}
+		return resp
+	case viewBio:
+		return map[string]interface{}{
+			"id":         user.UserID,
+			"gender":     user.Gender,
+			"birthdate":  birthdate,
+			"age":        age,
+			"hobbies":    user.Hobbies,
+			"about":      user.About,
+			"interests":  user.Interests,
+			"country":    user.Country,
+			"city":       city,
+			"attributes": attributesOrEmpty(user.Attributes),
+			"visiting":   visitingView(user, showCity),
+		}
+	default:
+		name := ""
+		if user.Fname != nil {
+			name = *user.Fname
+			if surname != nil {
+				name += " " + *surname
+			}
+		}
+		return map[string]interface{}{
+			"id":    user.UserID,
+			"name":  name,
+			"photo": photo,
+		}
+	}
+}
+
+func writeUserView(w http.ResponseWriter, user models.User, rel viewerRelation, view string) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(serializeUser(user, rel, view))
+}
+
+func attributesOrEmpty(attrs map[string]interface{}) map[string]interface{} {
+	if attrs == nil {
+		return map[string]interface{}{}
+	}
+	return attrs
+}
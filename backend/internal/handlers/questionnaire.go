@@ -0,0 +1,389 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"log"
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/jackc/pgx/v5"
+
+	"matchme-backend/internal/db"
+	"matchme-backend/internal/models"
+	"matchme-backend/internal/scoring"
+	"matchme-backend/internal/utils"
+)
+
+const (
+	maxQuestionLength  = 300
+	maxOptionLength    = 100
+	minQuestionOptions = 2
+	maxQuestionOptions = 6
+)
+
+// returns the questionnaire, only the questions users can answer unless all is set
+func loadQuestions(all bool) ([]models.Question, error) {
+	rows, err := db.Pool.Query(context.Background(), `
+        SELECT id, text, options, active
+        FROM questions
+        WHERE active OR $1
+        ORDER BY id
+    `, all)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	questions := []models.Question{}
+	for rows.Next() {
+		var q models.Question
+		if err := rows.Scan(&q.ID, &q.Text, &q.Options, &q.Active); err != nil {
+			return nil, err
+		}
+		questions = append(questions, q)
+	}
+	return questions, rows.Err()
+}
+
+// loads the answers the given users gave to active questions, keyed by user ID and question ID
+func loadAnswers(userIDs []int) (map[int]map[int]models.QuestionAnswer, error) {
+	result := make(map[int]map[int]models.QuestionAnswer)
+	if len(userIDs) == 0 {
+		return result, nil
+	}
+
+	rows, err := db.Pool.Query(context.Background(), `
+        SELECT a.user_id, a.question_id, a.answer, a.acceptable, a.importance
+        FROM user_answers a
+        JOIN questions q ON q.id = a.question_id
+        WHERE a.user_id = ANY($1) AND q.active
+    `, userIDs)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var userID, questionID int
+		var a models.QuestionAnswer
+		if err := rows.Scan(&userID, &questionID, &a.Answer, &a.Acceptable, &a.Importance); err != nil {
+			return nil, err
+		}
+		if result[userID] == nil {
+			result[userID] = make(map[int]models.QuestionAnswer)
+		}
+		result[userID][questionID] = a
+	}
+	return result, rows.Err()
+}
+
+// fills in the questionnaire answers of users loaded from the users table
+func attachAnswers(users []models.User) error {
+	ids := make([]int, len(users))
+	for i, u := range users {
+		ids[i] = u.UserID
+	}
+	answers, err := loadAnswers(ids)
+	if err != nil {
+		return err
+	}
+	for i := range users {
+		users[i].Answers = answers[users[i].UserID]
+	}
+	return nil
+}
+
+// the questionnaire match shown on another user's profile
+type compatibilityView struct {
+	Percentage int `json:"percentage"`
+	// questions both users answered
+	CommonQuestions int `json:"common_questions"`
+}
+
+// returns the viewer's questionnaire match with the target, or nil when
+// they have not answered any question in common
+func loadCompatibility(viewerID, targetID int) (*compatibilityView, error) {
+	answers, err := loadAnswers([]int{viewerID, targetID})
+	if err != nil {
+		return nil, err
+	}
+	match, common := scoring.Compatibility(answers[viewerID], answers[targetID])
+	if common == 0 {
+		return nil, nil
+	}
+	return &compatibilityView{Percentage: int(math.Round(match * 100)), CommonQuestions: common}, nil
+}
+
+type questionWithAnswer struct {
+	models.Question
+	// the user's answer, nil while unanswered
+	MyAnswer *models.QuestionAnswer `json:"my_answer"`
+}
+
+// GET /questions lists the questionnaire with the user's own answers
+func QuestionsHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	userIDStr, err := utils.ExtractUserIDFromTokenFromCookie(r)
+	if err != nil {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+	userID, _ := strconv.Atoi(userIDStr)
+
+	questions, err := loadQuestions(false)
+	if err != nil {
+		log.Printf("Error loading questions: %v\n", err)
+		http.Error(w, "Error retrieving questions", http.StatusInternalServerError)
+		return
+	}
+	answers, err := loadAnswers([]int{userID})
+	if err != nil {
+		log.Printf("Error loading answers: %v\n", err)
+		http.Error(w, "Error retrieving questions", http.StatusInternalServerError)
+		return
+	}
+
+	list := make([]questionWithAnswer, len(questions))
+	for i, q := range questions {
+		list[i] = questionWithAnswer{Question: q}
+		if a, ok := answers[userID][q.ID]; ok {
+			list[i].MyAnswer = &a
+		}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(list)
+}
+
+// POST /me/answers answers a question (again), DELETE /me/answers withdraws an answer
+func AnswersHandler(w http.ResponseWriter, r *http.Request) {
+	userIDStr, err := utils.ExtractUserIDFromTokenFromCookie(r)
+	if err != nil {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+	userID, _ := strconv.Atoi(userIDStr)
+
+	switch r.Method {
+	case http.MethodPost:
+		saveAnswer(w, r, userID)
+	case http.MethodDelete:
+		deleteAnswer(w, r, userID)
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+func saveAnswer(w http.ResponseWriter, r *http.Request, userID int) {
+	var body struct {
+		QuestionID int `json:"questionId"`
+		models.QuestionAnswer
+	}
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		http.Error(w, "Invalid JSON", http.StatusBadRequest)
+		return
+	}
+
+	var options []string
+	err := db.Pool.QueryRow(context.Background(), `
+        SELECT options FROM questions WHERE id = $1 AND active
+    `, body.QuestionID).Scan(&options)
+	if err == pgx.ErrNoRows {
+		http.Error(w, "Question not found", http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		log.Printf("Error loading question %d: %v\n", body.QuestionID, err)
+		http.Error(w, "Error saving answer", http.StatusInternalServerError)
+		return
+	}
+
+	a := body.QuestionAnswer
+	errs := ValidationErrors{}
+	if !containsFold(options, a.Answer) {
+		errs.add("answer", "must be one of %s", strings.Join(options, ", "))
+	}
+	if len(a.Acceptable) == 0 {
+		errs.add("acceptable", "at least one answer is required")
+	}
+	for _, v := range a.Acceptable {
+		if !containsFold(options, v) {
+			errs.add("acceptable", "values must be among %s", strings.Join(options, ", "))
+			break
+		}
+	}
+	a.Importance = strings.ToLower(strings.TrimSpace(a.Importance))
+	if a.Importance == "" {
+		a.Importance = scoring.ImportanceSomewhat
+	}
+	if !containsFold(scoring.ImportanceLevels, a.Importance) {
+		errs.add("importance", "must be one of %s", strings.Join(scoring.ImportanceLevels, ", "))
+	}
+	if len(errs) > 0 {
+		writeValidationErrors(w, errs)
+		return
+	}
+	a.Answer = canonicalOption(options, a.Answer)
+	a.Acceptable = *dedupeOptions(&a.Acceptable, options)
+
+	_, err = db.Pool.Exec(context.Background(), `
+        INSERT INTO user_answers (user_id, question_id, answer, acceptable, importance, answered_at)
+        VALUES ($1, $2, $3, $4, $5, NOW())
+        ON CONFLICT (user_id, question_id)
+        DO UPDATE SET answer = EXCLUDED.answer,
+                      acceptable = EXCLUDED.acceptable,
+                      importance = EXCLUDED.importance,
+                      answered_at = NOW()
+    `, userID, body.QuestionID, a.Answer, a.Acceptable, a.Importance)
+	if err != nil {
+		log.Printf("Error saving answer: %v\n", err)
+		http.Error(w, "Error saving answer", http.StatusInternalServerError)
+		return
+	}
+	markProfileChanged(userID)
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(a)
+}
+
+func deleteAnswer(w http.ResponseWriter, r *http.Request, userID int) {
+	var body struct {
+		QuestionID int `json:"questionId"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		http.Error(w, "Invalid JSON", http.StatusBadRequest)
+		return
+	}
+
+	tag, err := db.Pool.Exec(context.Background(), `
+        DELETE FROM user_answers WHERE user_id = $1 AND question_id = $2
+    `, userID, body.QuestionID)
+	if err != nil {
+		log.Printf("Error deleting answer: %v\n", err)
+		http.Error(w, "Error deleting answer", http.StatusInternalServerError)
+		return
+	}
+	if tag.RowsAffected() == 0 {
+		http.Error(w, "Answer not found", http.StatusNotFound)
+		return
+	}
+	markProfileChanged(userID)
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]string{
+		"message": "Answer deleted successfully",
+	})
+}
+
+// /admin/questions: GET lists all questions, POST adds one, DELETE retires one
+func AdminQuestionsHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Header.Get("X-Admin-Secret") != adminSecret {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	switch r.Method {
+	case http.MethodGet:
+		questions, err := loadQuestions(true)
+		if err != nil {
+			log.Printf("Error loading questions: %v\n", err)
+			http.Error(w, "Error retrieving questions", http.StatusInternalServerError)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(questions)
+	case http.MethodPost:
+		createQuestion(w, r)
+	case http.MethodDelete:
+		retireQuestion(w, r)
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+func createQuestion(w http.ResponseWriter, r *http.Request) {
+	var q models.Question
+	if err := json.NewDecoder(r.Body).Decode(&q); err != nil {
+		http.Error(w, "Invalid JSON", http.StatusBadRequest)
+		return
+	}
+
+	errs := ValidationErrors{}
+	q.Text = strings.TrimSpace(q.Text)
+	if q.Text == "" || len(q.Text) > maxQuestionLength {
+		errs.add("text", "must be 1-%d characters", maxQuestionLength)
+	}
+	q.Options = *dedupeOptions(&q.Options, nil)
+	if len(q.Options) < minQuestionOptions || len(q.Options) > maxQuestionOptions {
+		errs.add("options", "must have %d-%d distinct options", minQuestionOptions, maxQuestionOptions)
+	}
+	for _, o := range q.Options {
+		if o == "" || len(o) > maxOptionLength {
+			errs.add("options", "each option must be 1-%d characters", maxOptionLength)
+			break
+		}
+	}
+	if len(errs) > 0 {
+		writeValidationErrors(w, errs)
+		return
+	}
+
+	err := db.Pool.QueryRow(context.Background(), `
+        INSERT INTO questions (text, options)
+        VALUES ($1, $2)
+        ON CONFLICT (text) DO NOTHING
+        RETURNING id
+    `, q.Text, q.Options).Scan(&q.ID)
+	// ON CONFLICT DO NOTHING returns no row for a duplicate
+	if err == pgx.ErrNoRows {
+		http.Error(w, "Question already exists", http.StatusConflict)
+		return
+	}
+	if err != nil {
+		log.Printf("Error creating question: %v\n", err)
+		http.Error(w, "Error creating question", http.StatusInternalServerError)
+		return
+	}
+	q.Active = true
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(q)
+}
+
+// retired questions disappear from the questionnaire and stop counting
+// towards compatibility; answers are kept
+func retireQuestion(w http.ResponseWriter, r *http.Request) {
+	var body struct {
+		QuestionID int `json:"questionId"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		http.Error(w, "Invalid JSON", http.StatusBadRequest)
+		return
+	}
+
+	tag, err := db.Pool.Exec(context.Background(), `
+        UPDATE questions SET active = FALSE WHERE id = $1
+    `, body.QuestionID)
+	if err != nil {
+		log.Printf("Error retiring question: %v\n", err)
+		http.Error(w, "Error retiring question", http.StatusInternalServerError)
+		return
+	}
+	if tag.RowsAffected() == 0 {
+		http.Error(w, "Question not found", http.StatusNotFound)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]string{
+		"message": "Question retired successfully",
+	})
+}
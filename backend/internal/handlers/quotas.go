@@ -0,0 +1,267 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"log"
+	"net/http"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+
+	"matchme-backend/internal/db"
+	"matchme-backend/internal/scoring"
+	"matchme-backend/internal/utils"
+)
+
+// connection requests a user may send per local day; override with
+// MATCHME_DAILY_LIKES and MATCHME_DAILY_SUPER_LIKES
+var (
+	dailyLikeLimit      = envInt("MATCHME_DAILY_LIKES", 50)
+	dailySuperLikeLimit = envInt("MATCHME_DAILY_SUPER_LIKES", 1)
+)
+
+// the time zone decides when quotas reset, so it may only change this often
+const timezoneChangeInterval = 7 * 24 * time.Hour
+
+func envInt(name string, fallback int) int {
+	v := os.Getenv(name)
+	if v == "" {
+		return fallback
+	}
+	n, err := strconv.Atoi(v)
+	if err != nil || n < 0 {
+		log.Printf("Ignoring invalid %s=%q\n", name, v)
+		return fallback
+	}
+	return n
+}
+
+// the user's current day in their time zone; unknown zones count as UTC
+func quotaDay(timezone string, now time.Time) (start, end time.Time) {
+	loc, err := time.LoadLocation(timezone)
+	if err != nil {
+		loc = time.UTC
+	}
+	y, m, d := now.In(loc).Date()
+	start = time.Date(y, m, d, 0, 0, 0, 0, loc)
+	return start, start.AddDate(0, 0, 1)
+}
+
+type quotaUsage struct {
+	Limit     int `json:"limit"`
+	Used      int `json:"used"`
+	Remaining int `json:"remaining"`
+}
+
+func newQuotaUsage(limit, used int) quotaUsage {
+	remaining := limit - used
+	if remaining < 0 {
+		remaining = 0
+	}
+	return quotaUsage{Limit: limit, Used: used, Remaining: remaining}
+}
+
+type quotaStatus struct {
+	Timezone   string     `json:"timezone"`
+	ResetsAt   string     `json:"resets_at"`
+	Likes      quotaUsage `json:"likes"`
+	SuperLikes quotaUsage `json:"super_likes"`
+}
+
+// the pool or a transaction
+type rowQuerier interface {
+	QueryRow(ctx context.Context, sql string, args ...any) pgx.Row
+}
+
+// counts the likes the user sent today; a super like also uses a like
+func loadQuotaStatus(q rowQuerier, userID int) (quotaStatus, error) {
+	var status quotaStatus
+	if err := q.QueryRow(context.Background(), `
+        SELECT timezone FROM users WHERE id = $1
+    `, userID).Scan(&status.Timezone); err != nil {
+		return status, err
+	}
+	start, end := quotaDay(status.Timezone, time.Now())
+
+	var likes, superLikes int
+	if err := q.QueryRow(context.Background(), `
+        SELECT COUNT(*), COUNT(*) FILTER (WHERE super_like)
+        FROM sent_likes
+        WHERE user_id = $1 AND sent_at >= $2
+    `, userID, start).Scan(&likes, &superLikes); err != nil {
+		return status, err
+	}
+	status.ResetsAt = end.UTC().Format(time.RFC3339)
+	status.Likes = newQuotaUsage(dailyLikeLimit, likes)
+	status.SuperLikes = newQuotaUsage(dailySuperLikeLimit, superLikes)
+	return status, nil
+}
+
+// GET /me/quotas shows how many likes and super likes are left today
+func QuotasHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	userIDStr, err := utils.ExtractUserIDFromTokenFromCookie(r)
+	if err != nil {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+	userID, _ := strconv.Atoi(userIDStr)
+
+	status, err := loadQuotaStatus(db.Pool, userID)
+	if err != nil {
+		log.Printf("Error loading quotas: %v\n", err)
+		http.Error(w, "Error retrieving quotas", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(status)
+}
+
+// outcome of sending a like
+type likeResult int
+
+const (
+	likeSent likeResult = iota
+	// an identical request is already pending, or the request was already
+	// answered (accepted or rejected)
+	likeAlreadySent
+	likeQuotaExceeded
+	superLikeQuotaExceeded
+)
+
+// sends a connection request (a like) within the daily quotas, or upgrades
+// a pending request to a super like
+func sendLike(userID, targetID int, superLike bool) (likeResult, error) {
+	ctx := context.Background()
+	tx, err := db.Pool.Begin(ctx)
+	if err != nil {
+		return likeSent, err
+	}
+	defer tx.Rollback(ctx)
+
+	// serializes the sender's likes so concurrent requests cannot overrun the quota
+	if _, err := tx.Exec(ctx, `SELECT 1 FROM users WHERE id = $1 FOR UPDATE`, userID); err != nil {
+		return likeSent, err
+	}
+	status, err := loadQuotaStatus(tx, userID)
+	if err != nil {
+		return likeSent, err
+	}
+
+	// an existing request is settled before the quotas: repeating it costs
+	// nothing, only upgrading a pending one to a super like does
+	var existingStatus string
+	var existingSuper bool
+	err = tx.QueryRow(ctx, `
+        SELECT status, super_like FROM connections
+        WHERE user_id = $1 AND connected_user_id = $2
+    `, userID, targetID).Scan(&existingStatus, &existingSuper)
+	if err != nil && err != pgx.ErrNoRows {
+		return likeSent, err
+	}
+	if err == nil && (existingStatus != "pending" || !superLike || existingSuper) {
+		return likeAlreadySent, nil
+	}
+
+	if status.Likes.Remaining == 0 {
+		return likeQuotaExceeded, nil
+	}
+	if superLike && status.SuperLikes.Remaining == 0 {
+		return superLikeQuotaExceeded, nil
+	}
+
+	tag, err := tx.Exec(ctx, `
+        INSERT INTO connections (user_id, connected_user_id, status, super_like)
+        VALUES ($1, $2, 'pending', $3)
+        ON CONFLICT (user_id, connected_user_id) DO UPDATE
+        SET super_like = TRUE
+        WHERE connections.status = 'pending' AND EXCLUDED.super_like
+    `, userID, targetID, superLike)
+	if err != nil {
+		return likeSent, err
+	}
+	if tag.RowsAffected() == 0 {
+		return likeAlreadySent, nil
+	}
+
+	if _, err := tx.Exec(ctx, `
+        INSERT INTO sent_likes (user_id, target_id, super_like) VALUES ($1, $2, $3)
+    `, userID, targetID, superLike); err != nil {
+		return likeSent, err
+	}
+	return likeSent, tx.Commit(ctx)
+}
+
+// the senders of the viewer's pending super likes, newest first; only those
+// the strategy could recommend to the viewer and the viewer did not dismiss
+func pendingSuperLikes(viewerID int, strategy *scoring.Strategy) ([]int, error) {
+	rows, err := db.Pool.Query(context.Background(), `
+        SELECT c.user_id
+        FROM connections c
+        WHERE c.connected_user_id = $1
+          AND c.status = 'pending'
+          AND c.super_like
+          AND c.user_id IN (
+              SELECT id FROM users
+              WHERE id <> $1
+                AND `+candidateConditions(strategy)+`
+          )
+          AND c.user_id NOT IN (`+activeDismissalsQuery+`)
+        ORDER BY c.created_at DESC, c.user_id
+    `, viewerID, strategy.InactiveAfterDays)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var ids []int
+	for rows.Next() {
+		var id int
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+	return ids, rows.Err()
+}
+
+// moves pending super likes to the top of the viewer's recommendations
+func prioritizeSuperLikes(viewerID int, scored, pool []userWithScore, limit int, strategy *scoring.Strategy) ([]userWithScore, error) {
+	senders, err := pendingSuperLikes(viewerID, strategy)
+	if err != nil {
+		return nil, err
+	}
+
+	stored := make(map[int]userWithScore, len(pool))
+	for _, sc := range pool {
+		stored[sc.ID] = sc
+	}
+	var result []userWithScore
+	seen := make(map[int]bool)
+	for _, id := range senders {
+		sc, ok := stored[id]
+		if !ok {
+			sc = userWithScore{ID: id}
+		}
+		result = append(result, sc)
+		seen[id] = true
+	}
+
+	for _, sc := range scored {
+		if !seen[sc.ID] {
+			result = append(result, sc)
+		}
+	}
+	if len(result) > limit {
+		result = result[:limit]
+	}
+	return result, nil
+}
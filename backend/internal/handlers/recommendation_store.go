@@ -0,0 +1,361 @@
+package handlers
+
+import (
+	"context"
+	"log"
+	"sort"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+
+	"matchme-backend/internal/db"
+	"matchme-backend/internal/models"
+	"matchme-backend/internal/scoring"
+	"matchme-backend/internal/utils"
+)
+
+const (
+	// how many candidates are precomputed per user; the handler serves from this pool
+	recommendationPoolSize = 200
+	// how many different viewers a candidate is shown to per day; people already
+	// shown to a viewer today stay in that viewer's list
+	dailyExposureCap = 50
+	// a pool that runs low is refilled at most this often, so users with few
+	// candidates are not recomputed on every request
+	minRefillInterval = 10 * time.Minute
+)
+
+// scores every potential match for the viewer and returns the best first
+func scoreCandidates(viewerID int, strategy *scoring.Strategy) ([]userWithScore, error) {
+	viewer, err := getUserByID(viewerID)
+	if err != nil {
+		return nil, err
+	}
+	dismissedIDs, err := loadDismissedIDs(viewerID)
+	if err != nil {
+		return nil, err
+	}
+	potential, err := fetchPotentialMatches(viewerID, dismissedIDs, strategy)
+	if err != nil {
+		return nil, err
+	}
+
+	// the viewer is active whenever their list is rebuilt, which matters
+	// when scoring in both directions
+	now := time.Now()
+	viewer.LastActiveAt = &now
+	if err := prepareScoring(&viewer, potential, strategy); err != nil {
+		return nil, err
+	}
+
+	var scored []userWithScore
+	for _, m := range potential {
+		s, skip := strategy.Score(viewer, m)
+		if skip || s <= 0 {
+			continue
+		}
+		scored = append(scored, userWithScore{ID: m.UserID, Score: s, User: m})
+	}
+
+	sort.Slice(scored, func(i, j int) bool {
+		if scored[i].Score != scored[j].Score {
+			return scored[i].Score > scored[j].Score
+		}
+		return scored[i].ID < scored[j].ID
+	})
+	return scored, nil
+}
+
+// switches everyone to the location of an ongoing trip and loads what the
+// strategy's signals need beyond the profiles themselves: collaborative
+// affinities, bio vectors and questionnaire answers
+func prepareScoring(viewer *models.User, candidates []models.User, strategy *scoring.Strategy) error {
+	useTravelLocation(viewer)
+	for i := range candidates {
+		useTravelLocation(&candidates[i])
+	}
+	if err := attachAffinities(viewer, candidates, strategy); err != nil {
+		return err
+	}
+	viewers := []models.User{*viewer}
+	if strategy.Uses(scoring.BioSignal{}.Name()) {
+		if err := attachBioVectors(viewers); err != nil {
+			return err
+		}
+		viewer.BioVector = viewers[0].BioVector
+		if err := attachBioVectors(candidates); err != nil {
+			return err
+		}
+	}
+	if strategy.Uses(scoring.CompatibilitySignal{}.Name()) {
+		if err := attachAnswers(viewers); err != nil {
+			return err
+		}
+		viewer.Answers = viewers[0].Answers
+		if err := attachAnswers(candidates); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// recomputes the user's top candidates with their strategy (which depends on
+// the running experiment) and stores them as a new generation
+func RecomputeRecommendations(userID int) error {
+	strategy, experiment, variant := scoring.StrategyFor(userID)
+	scored, err := scoreCandidates(userID, strategy)
+	if err != nil {
+		return err
+	}
+	if len(scored) > recommendationPoolSize {
+		scored = scored[:recommendationPoolSize]
+	}
+
+	ctx := context.Background()
+	tx, err := db.Pool.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	var generation int
+	err = tx.QueryRow(ctx, `
+        INSERT INTO recommendation_state (user_id, generation, computed_at, stale)
+        VALUES ($1, 1, NOW(), FALSE)
+        ON CONFLICT (user_id) DO UPDATE
+        SET generation = recommendation_state.generation + 1,
+            computed_at = NOW(),
+            stale = FALSE
+        RETURNING generation
+    `, userID).Scan(&generation)
+	if err != nil {
+		return err
+	}
+
+	batch := &pgx.Batch{}
+	for _, sc := range scored {
+		batch.Queue(`
+            INSERT INTO recommendations (user_id, recommended_user_id, score, generation, experiment, variant)
+            VALUES ($1, $2, $3, $4, NULLIF($5, ''), NULLIF($6, ''))
+            ON CONFLICT (user_id, recommended_user_id)
+            DO UPDATE SET score = EXCLUDED.score, generation = EXCLUDED.generation, created_at = NOW(),
+                          experiment = EXCLUDED.experiment, variant = EXCLUDED.variant
+        `, userID, sc.ID, sc.Score, generation, experiment, variant)
+	}
+	if err := tx.SendBatch(ctx, batch).Close(); err != nil {
+		return err
+	}
+	// candidates who dropped out of the pool, served or not, are gone for good
+	if _, err := tx.Exec(ctx, `
+        DELETE FROM recommendations WHERE user_id = $1 AND generation <> $2
+    `, userID, generation); err != nil {
+		return err
+	}
+
+	return tx.Commit(ctx)
+}
+
+// flags a user's precomputed recommendations for recomputation by the worker
+func markRecommendationsStale(userID int) {
+	_, err := db.Pool.Exec(context.Background(), `
+        INSERT INTO recommendation_state (user_id, stale)
+        VALUES ($1, TRUE)
+        ON CONFLICT (user_id) DO UPDATE SET stale = TRUE
+    `, userID)
+	if err != nil {
+		log.Printf("Error marking recommendations stale for %d: %v\n", userID, err)
+	}
+}
+
+// flags the user's recommendations and those of everyone the user is stored
+// for, after a change to how the user scores or whether they qualify
+func markProfileChanged(userID int) {
+	_, err := db.Pool.Exec(context.Background(), `
+        INSERT INTO recommendation_state (user_id, stale)
+        SELECT $1::int, TRUE
+        UNION
+        SELECT user_id, TRUE FROM recommendations WHERE recommended_user_id = $1
+        ON CONFLICT (user_id) DO UPDATE SET stale = TRUE
+    `, userID)
+	if err != nil {
+		log.Printf("Error marking recommendations stale for changes to %d: %v\n", userID, err)
+	}
+}
+
+// asks the worker to refill a pool that is running low, unless it was
+// computed within minRefillInterval
+func requestRecommendationRefill(userID int) {
+	_, err := db.Pool.Exec(context.Background(), `
+        UPDATE recommendation_state SET stale = TRUE
+        WHERE user_id = $1 AND (computed_at IS NULL OR computed_at < NOW() - make_interval(secs => $2))
+    `, userID, minRefillInterval.Seconds())
+	if err != nil {
+		log.Printf("Error requesting recommendation refill for %d: %v\n", userID, err)
+	}
+}
+
+// returns users with a complete profile whose recommendations are missing,
+// flagged stale or older than maxAge, oldest first
+func StaleRecommendationUsers(limit int, maxAge time.Duration) ([]int, error) {
+	rows, err := db.Pool.Query(context.Background(), `
+        SELECT u.id
+        FROM users u
+        LEFT JOIN recommendation_state s ON s.user_id = u.id
+        WHERE `+utils.CompleteProfileCondition("u")+`
+          AND (s.user_id IS NULL
+               OR s.stale
+               OR s.computed_at IS NULL
+               OR s.computed_at < NOW() - make_interval(secs => $1))
+        ORDER BY s.computed_at NULLS FIRST
+        LIMIT $2
+    `, maxAge.Seconds(), limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var ids []int
+	for rows.Next() {
+		var id int
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+	return ids, rows.Err()
+}
+
+// recomputes recommendations for every user with a complete profile
+// returns how many users were rebuilt
+func RebuildAllRecommendations() (int, error) {
+	rows, err := db.Pool.Query(context.Background(), `
+        SELECT id FROM users WHERE `+utils.CompleteProfileCondition("")+` ORDER BY id
+    `)
+	if err != nil {
+		return 0, err
+	}
+	var ids []int
+	for rows.Next() {
+		var id int
+		if err := rows.Scan(&id); err != nil {
+			rows.Close()
+			return 0, err
+		}
+		ids = append(ids, id)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return 0, err
+	}
+
+	rebuilt := 0
+	for _, id := range ids {
+		if err := RecomputeRecommendations(id); err != nil {
+			log.Printf("Error rebuilding recommendations for %d: %v\n", id, err)
+			continue
+		}
+		rebuilt++
+	}
+	return rebuilt, nil
+}
+
+// narrows down which stored recommendations are read
+type storedRecommendationQuery struct {
+	Limit int
+	// keyset position: only rows ordered after (AfterScore, AfterID)
+	AfterScore *string
+	AfterID    int
+	// skip rows served more recently than this
+	NotServedSince *time.Time
+	// skip candidates already shown to this many other viewers today; 0 for no cap
+	ExposureCap int
+}
+
+// reads the viewer's current precomputed recommendations, best first, dropping
+// anyone dismissed, connected or requested either way, or no longer visible
+// since they were computed; people who sent a request are in the inbox instead
+// computed is false when the user has never had recommendations computed
+func loadStoredRecommendations(viewerID int, q storedRecommendationQuery) (recs []userWithScore, computed bool, err error) {
+	err = db.Pool.QueryRow(context.Background(), `
+        SELECT EXISTS (SELECT 1 FROM recommendation_state WHERE user_id = $1 AND computed_at IS NOT NULL)
+    `, viewerID).Scan(&computed)
+	if err != nil || !computed {
+		return nil, computed, err
+	}
+
+	rows, err := db.Pool.Query(context.Background(), `
+        SELECT r.recommended_user_id, r.score::float8, r.score::text
+        FROM recommendations r
+        JOIN recommendation_state s ON s.user_id = r.user_id AND s.generation = r.generation
+        JOIN users u ON u.id = r.recommended_user_id
+        WHERE r.user_id = $1
+          AND `+visibleCandidateCondition("u")+`
+          AND r.recommended_user_id NOT IN (`+activeDismissalsQuery+`)
+          AND r.recommended_user_id NOT IN (`+blockedUsersQuery+`)
+          AND r.recommended_user_id NOT IN (
+              SELECT connected_user_id FROM connections WHERE user_id = $1
+              UNION
+              SELECT user_id FROM connections WHERE connected_user_id = $1
+          )
+          AND ($3::numeric IS NULL
+               OR r.score < $3::numeric
+               OR (r.score = $3::numeric AND r.recommended_user_id > $4))
+          AND ($5::timestamptz IS NULL OR r.served_at IS NULL OR r.served_at < $5)
+          AND ($6::int = 0
+               OR r.recommended_user_id IN (
+                   SELECT candidate_id FROM recommendation_exposures
+                   WHERE viewer_id = $1 AND day = CURRENT_DATE
+               )
+               OR (SELECT COUNT(*) FROM recommendation_exposures e
+                   WHERE e.candidate_id = r.recommended_user_id AND e.day = CURRENT_DATE) < $6)
+        ORDER BY r.score DESC, r.recommended_user_id
+        LIMIT $2
+    `, viewerID, q.Limit, q.AfterScore, q.AfterID, q.NotServedSince, q.ExposureCap)
+	if err != nil {
+		return nil, true, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var rec userWithScore
+		if err := rows.Scan(&rec.ID, &rec.Score, &rec.ScoreText); err != nil {
+			return nil, true, err
+		}
+		recs = append(recs, rec)
+	}
+	return recs, true, rows.Err()
+}
+
+// records that these recommendations were shown to the viewer, both on the
+// current rows and in the served log kept for offline evaluation and
+// experiment reports
+func markRecommendationsServed(viewerID int, ids []int) error {
+	if len(ids) == 0 {
+		return nil
+	}
+	_, err := db.Pool.Exec(context.Background(), `
+        WITH served AS (
+            UPDATE recommendations
+            SET served_at = NOW()
+            WHERE user_id = $1 AND recommended_user_id = ANY($2)
+            RETURNING recommended_user_id, experiment, variant
+        )
+        INSERT INTO served_recommendations (user_id, recommended_user_id, experiment, variant)
+        SELECT $1, recommended_user_id, experiment, variant FROM served
+        ON CONFLICT DO NOTHING
+    `, viewerID, ids)
+	return err
+}
+
+// counts today's exposure of these candidates to the viewer
+func recordExposures(viewerID int, ids []int) error {
+	if len(ids) == 0 {
+		return nil
+	}
+	_, err := db.Pool.Exec(context.Background(), `
+        INSERT INTO recommendation_exposures (candidate_id, viewer_id, day)
+        SELECT unnest($2::int[]), $1, CURRENT_DATE
+        ON CONFLICT DO NOTHING
+    `, viewerID, ids)
+	return err
+}
@@ -6,18 +6,15 @@ import (
 	"log"
 	"matchme-backend/internal/db"
 	"matchme-backend/internal/models"
+	"matchme-backend/internal/scoring"
 	"matchme-backend/internal/utils"
 	"net/http"
-	"sort"
 	"strconv"
 	"strings"
 	"time"
 )
 
-const (
-	maxRecommendations = 10
-	minScoreThreshold  = 8.0
-)
+const maxRecommendations = 10
 
 func RecommendationsHandler(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodGet {
@@ -32,75 +29,88 @@ func RecommendationsHandler(w http.ResponseWriter, r *http.Request) {
 	}
 	userID, _ := strconv.Atoi(userIDStr)
 
-	// 1. get the viewer's profile data
-	viewer, err := getUserByID(userID)
+	// 1. read the best precomputed recommendations, computing them now on first use;
+	// candidates shown to many others today are left out
+	query := storedRecommendationQuery{Limit: rerankPoolSize, ExposureCap: dailyExposureCap}
+	pool, computed, err := loadStoredRecommendations(userID, query)
+	if err == nil && !computed {
+		if err = RecomputeRecommendations(userID); err == nil {
+			pool, _, err = loadStoredRecommendations(userID, query)
+		}
+	}
 	if err != nil {
-		log.Printf("Error fetching viewer user: %v\n", err)
-		http.Error(w, "Error retrieving viewer data", http.StatusInternalServerError)
+		log.Printf("Error loading recommendations: %v\n", err)
+		http.Error(w, "Error retrieving matches", http.StatusInternalServerError)
 		return
 	}
 
-	// 2. fetch potential matches
-	//    skipping those the viewer has dismissed or is the same user
-	dismissedIDs, err := loadDismissedIDs(userID)
+	// 2. running low on candidates: let the worker refill the pool
+	if len(pool) < maxRecommendations {
+		requestRecommendationRefill(userID)
+	}
+
+	// 3. re-rank so the list is not all alike
+	scored, err := diversify(pool, maxRecommendations)
 	if err != nil {
-		log.Printf("Error loading dismissed IDs: %v\n", err)
+		log.Printf("Error re-ranking recommendations: %v\n", err)
 		http.Error(w, "Error retrieving matches", http.StatusInternalServerError)
 		return
 	}
-
-	potential, err := fetchPotentialMatches(userID, dismissedIDs)
-	if err != nil {
-		log.Printf("Error fetching potential matches: %v\n", err)
+	// 4. people who super liked the viewer come first
+	strategy, _, _ := scoring.StrategyFor(userID)
+	if scored, err = prioritizeSuperLikes(userID, scored, pool, maxRecommendations, strategy); err != nil {
+		log.Printf("Error loading super likes: %v\n", err)
 		http.Error(w, "Error retrieving matches", http.StatusInternalServerError)
 		return
 	}
-
-	// 3. score them
-	var scored []userWithScore
-	for _, m := range potential {
-		s, skip := computeMatchScore(viewer, m)
-		if skip {
-			continue
-		}
-		if s > 0 {
-			scored = append(scored, userWithScore{
-				ID:    m.UserID,
-				Score: s,
-			})
-		}
+	ids := make([]int, len(scored))
+	for i, sc := range scored {
+		ids[i] = sc.ID
 	}
-
-	// 4. sort by descending score (best first)
-	sort.Slice(scored, func(i, j int) bool {
-		return scored[i].Score > scored[j].Score
-	})
-
-	// 5. take top N recommendations
-	if len(scored) > maxRecommendations {
-		scored = scored[:maxRecommendations]
+	if err := markRecommendationsServed(userID, ids); err != nil {
+		log.Printf("Error marking recommendations served: %v\n", err)
 	}
-
-	// 6. return just the IDs
-	resultIDs := make([]int, len(scored))
-	for i, sc := range scored {
-		resultIDs[i] = sc.ID
+	if err := recordExposures(userID, ids); err != nil {
+		log.Printf("Error recording exposures: %v\n", err)
 	}
 
-	// after computing matches, insert them into the database
-	for _, match := range scored {
-		_, err := db.Pool.Exec(context.Background(), `
-        INSERT INTO recommendations (user_id, recommended_user_id, score)
-        VALUES ($1, $2, $3)
-        ON CONFLICT DO NOTHING
-    `, userID, match.ID, match.Score)
+	w.Header().Set("Content-Type", "application/json")
+	if r.URL.Query().Get("explain") == "true" {
+		viewer, err := getUserByID(userID)
 		if err != nil {
-			log.Printf("Failed to save recommendation for %d -> %d: %v", userID, match.ID, err)
+			log.Printf("Error fetching viewer user: %v\n", err)
+			http.Error(w, "Error retrieving viewer data", http.StatusInternalServerError)
+			return
+		}
+		now := time.Now()
+		viewer.LastActiveAt = &now
+
+		var targets []models.User
+		var stored []float64
+		for _, sc := range scored {
+			target, err := getUserByID(sc.ID)
+			if err != nil {
+				continue
+			}
+			targets = append(targets, target)
+			stored = append(stored, sc.Score)
+		}
+		if err := prepareScoring(&viewer, targets, strategy); err != nil {
+			log.Printf("Error loading scoring data: %v\n", err)
 		}
+
+		explained := make([]recommendationExplanation, 0, len(targets))
+		for i, target := range targets {
+			exp := explainRecommendation(viewer, target, relationEligible)
+			exp.StoredScore = &stored[i]
+			explained = append(explained, exp)
+		}
+		json.NewEncoder(w).Encode(explained)
+		return
 	}
 
-	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(resultIDs)
+	// 5. return just the IDs
+	json.NewEncoder(w).Encode(ids)
 }
 
 func DismissRecommendationHandler(w http.ResponseWriter, r *http.Request) {
@@ -118,22 +128,47 @@ func DismissRecommendationHandler(w http.ResponseWriter, r *http.Request) {
 
 	var body struct {
 		DismissedUserID int `json:"dismissedUserId"`
+		// optional: after this many days the user may resurface if their profile changed
+		ExpiresInDays *int `json:"expiresInDays"`
 	}
 	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
 		http.Error(w, "Invalid JSON", http.StatusBadRequest)
 		return
 	}
+	if body.ExpiresInDays != nil && (*body.ExpiresInDays < 1 || *body.ExpiresInDays > maxDismissDays) {
+		errs := ValidationErrors{}
+		errs.add("expiresInDays", "must be between 1 and %d", maxDismissDays)
+		writeValidationErrors(w, errs)
+		return
+	}
 
-	_, err = db.Pool.Exec(context.Background(), `
-        INSERT INTO dismissed_recommendations (user_id, dismissed_user_id)
-        VALUES ($1, $2)
-        ON CONFLICT DO NOTHING
-    `, userID, body.DismissedUserID)
+	// dismissing again restarts the dismissal
+	tag, err := db.Pool.Exec(context.Background(), `
+        INSERT INTO dismissed_recommendations (user_id, dismissed_user_id, expires_at, `+dismissalSnapshotColumns+`)
+        SELECT $1, u.id,
+               CASE WHEN $3::int IS NULL THEN NULL ELSE NOW() + make_interval(days => $3::int) END,
+               `+dismissalSnapshotValues+`
+        FROM users u
+        WHERE u.id = $2
+        ON CONFLICT (user_id, dismissed_user_id) DO UPDATE
+        SET created_at = CURRENT_TIMESTAMP,
+            expires_at = EXCLUDED.expires_at,
+            snapshot_about = EXCLUDED.snapshot_about,
+            snapshot_hobbies = EXCLUDED.snapshot_hobbies,
+            snapshot_interests = EXCLUDED.snapshot_interests,
+            snapshot_photo = EXCLUDED.snapshot_photo,
+            snapshot_city = EXCLUDED.snapshot_city,
+            profile_changed = FALSE
+    `, userID, body.DismissedUserID, body.ExpiresInDays)
 	if err != nil {
 		log.Printf("Error dismissing recommendation: %v\n", err)
 		http.Error(w, "Error dismissing recommendation", http.StatusInternalServerError)
 		return
 	}
+	if tag.RowsAffected() == 0 {
+		http.Error(w, "User not found", http.StatusNotFound)
+		return
+	}
 
 	w.WriteHeader(http.StatusOK)
 	json.NewEncoder(w).Encode(map[string]string{
@@ -142,7 +177,12 @@ func DismissRecommendationHandler(w http.ResponseWriter, r *http.Request) {
 }
 
 // returns all users with a complete profile that match the viewer's location
-func fetchPotentialMatches(viewerID int, dismissed map[int]bool) ([]models.User, error) {
+// (same city or same country, depending on the strategy's candidate scope;
+// candidates are loaded with the location of an ongoing trip);
+// only users who meet the viewer's hard preferences are returned and, for
+// reciprocal strategies, only users whose own hard preferences accept the
+// viewer; users dormant for longer than the strategy allows are left out
+func fetchPotentialMatches(viewerID int, dismissed map[int]bool, strategy *scoring.Strategy) ([]models.User, error) {
 	rows, err := db.Pool.Query(context.Background(), `
         SELECT
             id,
@@ -155,42 +195,36 @@ func fetchPotentialMatches(viewerID int, dismissed map[int]bool) ([]models.User,
             about,
             hobbies,
             interests,
-            country,
-            city,
+            `+effectiveLocationSQL("country")+` AS country,
+            `+effectiveLocationSQL("city")+` AS city,
             looking_for_gender,
             looking_for_min_age,
             looking_for_max_age,
-            profile_picture_url
+            profile_picture_url,
+            preferred_hobbies,
+            preferred_interests,
+            city_visibility,
+            last_active_at,
+            profile_completed_at,
+            gender_strictness,
+            age_strictness,
+            age_tolerance,
+            min_shared_hobbies,
+            min_shared_interests,
+            shared_strictness
         FROM users
         WHERE id <> $1
-          AND fname IS NOT NULL
-          AND surname IS NOT NULL
-          AND gender IS NOT NULL
-          AND birthdate IS NOT NULL
-          AND hobbies IS NOT NULL
-          AND about IS NOT NULL
-          AND interests IS NOT NULL
-          AND country IS NOT NULL
-          AND city IS NOT NULL
-          AND looking_for_gender IS NOT NULL
-          AND looking_for_min_age IS NOT NULL
-          AND looking_for_max_age IS NOT NULL
-          -- Only return users in the same country and city as the viewer
-          AND country = (SELECT country FROM users WHERE id = $1)
-          AND city = (SELECT city FROM users WHERE id = $1)
-          AND (
-              id NOT IN (
-                  SELECT connected_user_id FROM connections WHERE user_id = $1
-                  UNION
-                  SELECT user_id FROM connections WHERE connected_user_id = $1
-                  UNION
-                  SELECT dismissed_user_id FROM dismissed_recommendations WHERE user_id = $1
-              )
-              OR id IN (
-                  SELECT user_id FROM connections WHERE connected_user_id = $1 AND status = 'pending'
-              )
+          AND `+candidateConditions(strategy)+`
+          -- Leave out anyone with a connection or request either way (pending
+          -- requests are in the inbox) and active dismissals
+          AND id NOT IN (
+              SELECT connected_user_id FROM connections WHERE user_id = $1
+              UNION
+              SELECT user_id FROM connections WHERE connected_user_id = $1
+              UNION
+              `+activeDismissalsQuery+`
           )
-    `, viewerID)
+    `, viewerID, strategy.InactiveAfterDays)
 	if err != nil {
 		return nil, err
 	}
@@ -216,6 +250,17 @@ func fetchPotentialMatches(viewerID int, dismissed map[int]bool) ([]models.User,
 			&u.LookingForMinAge,
 			&u.LookingForMaxAge,
 			&u.Picture,
+			&u.PreferredHobbies,
+			&u.PreferredInterests,
+			&u.CityVisibility,
+			&u.LastActiveAt,
+			&u.ProfileCompletedAt,
+			&u.GenderStrictness,
+			&u.AgeStrictness,
+			&u.AgeTolerance,
+			&u.MinSharedHobbies,
+			&u.MinSharedInterests,
+			&u.SharedStrictness,
 		)
 		if err != nil {
 			return nil, err
@@ -225,15 +270,19 @@ func fetchPotentialMatches(viewerID int, dismissed map[int]bool) ([]models.User,
 		}
 		users = append(users, u)
 	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	if err := attachAttributes(users); err != nil {
+		return nil, err
+	}
 	return users, nil
 }
 
+// returns the users the viewer dismissed who are still hidden
 func loadDismissedIDs(viewerID int) (map[int]bool, error) {
-	rows, err := db.Pool.Query(context.Background(), `
-        SELECT dismissed_user_id
-        FROM dismissed_recommendations
-        WHERE user_id = $1
-    `, viewerID)
+	rows, err := db.Pool.Query(context.Background(), activeDismissalsQuery, viewerID)
 	if err != nil {
 		return nil, err
 	}
@@ -249,100 +298,36 @@ func loadDismissedIDs(viewerID int) (map[int]bool, error) {
 	return dismissed, nil
 }
 
+// SQL conditions on an unqualified row of users for the viewer in $1: a
+// complete, visible profile in the viewer's location scope that meets the
+// hard preferences, neither side blocked and not dormant for longer than the
+// strategy allows (the number of days in $2)
+func candidateConditions(strategy *scoring.Strategy) string {
+	cityCondition := ""
+	if strategy.CandidateScope != scoring.ScopeCountry {
+		cityCondition = "AND " + effectiveLocationSQL("city") + " = (SELECT " + effectiveLocationSQL("city") + " FROM users WHERE id = $1)"
+	}
+	return utils.CompleteProfileCondition("") + `
+          AND ` + visibleCandidateCondition("") + `
+          -- Only return users in the same country (and city, depending on scope) as the viewer,
+          -- counting travel locations while a trip is on
+          AND ` + effectiveLocationSQL("country") + ` = (SELECT ` + effectiveLocationSQL("country") + ` FROM users WHERE id = $1)
+          ` + cityCondition + `
+          ` + preferenceConditions(strategy.Reciprocal) + `
+          -- Leave out users blocked in either direction
+          AND id NOT IN (` + blockedUsersQuery + `)
+          -- Leave out dormant accounts
+          AND ($2::float8 = 0
+               OR last_active_at IS NULL
+               OR last_active_at >= NOW() - make_interval(secs => $2::float8 * 86400))`
+}
+
 type userWithScore struct {
 	ID    int
 	Score float64
-}
-
-// computes a matching score between the viewer and the target
-// it considers basic location, age, and gender requirements, as well as matching hobbies and interests
-// applying a multiplier for items that the viewer has marked as preferred
-// finally, if the computed score is below minScoreThreshold, the candidate is skipped
-func computeMatchScore(viewer models.User, target models.User) (float64, bool) {
-	var score float64
-
-	// 1) require same country
-	if viewer.Country == nil || target.Country == nil || !strings.EqualFold(*viewer.Country, *target.Country) {
-		return 0, true
-	}
-	score += 1
-
-	// 1b) require same city
-	if viewer.City == nil || target.City == nil || !strings.EqualFold(*viewer.City, *target.City) {
-		return 0, true
-	}
-	score += 2
-
-	// 2) skip if target’s age is outside viewer's min–max range
-	targetAge := calcAge(*target.Birthdate)
-	if viewer.LookingForMinAge == nil || viewer.LookingForMaxAge == nil {
-		return 0, true
-	}
-	if targetAge < *viewer.LookingForMinAge || targetAge > *viewer.LookingForMaxAge {
-		return 0, true
-	}
-	score += 2
-
-	// 3) gender check. If viewer is looking for a specific gender, require it
-	if viewer.LookingForGender != nil && strings.ToLower(*viewer.LookingForGender) != "any" {
-		if target.Gender == nil || !strings.EqualFold(*viewer.LookingForGender, *target.Gender) {
-			return 0, true
-		}
-		score += 2
-	}
-
-	// 4) hobbies: for each matching hobby, if it is also preferred by the viewer, count double
-	var hobbyScore float64
-	if viewer.Hobbies != nil && target.Hobbies != nil {
-		for _, tHobby := range *target.Hobbies {
-			for _, vHobby := range *viewer.Hobbies {
-				if strings.EqualFold(tHobby, vHobby) {
-					multiplier := 1.0
-					if viewer.PreferredHobbies != nil {
-						for _, pref := range *viewer.PreferredHobbies {
-							if strings.EqualFold(pref, tHobby) {
-								multiplier = 2.0
-								break
-							}
-						}
-					}
-					hobbyScore += multiplier
-					break
-				}
-			}
-		}
-	}
-	score += hobbyScore
-
-	// 5) interests: similar logic with multiplier
-	var interestScore float64
-	if viewer.Interests != nil && target.Interests != nil {
-		for _, tInterest := range *target.Interests {
-			for _, vInterest := range *viewer.Interests {
-				if strings.EqualFold(tInterest, vInterest) {
-					multiplier := 1.0
-					if viewer.PreferredInterests != nil {
-						for _, pref := range *viewer.PreferredInterests {
-							if strings.EqualFold(pref, tInterest) {
-								multiplier = 2.0
-								break
-							}
-						}
-					}
-					interestScore += multiplier
-					break
-				}
-			}
-		}
-	}
-	score += interestScore
-
-	// enforce a minimal score threshold
-	if score < minScoreThreshold {
-		return score, true
-	}
-
-	return score, false
+	User  models.User
+	// the score exactly as stored, used in feed cursors
+	ScoreText string
 }
 
 // returns how many items match ignoring case
@@ -359,19 +344,3 @@ func intersectionLen(a, b []string) int {
 	}
 	return count
 }
-
-func calcAge(birthdate string) int {
-	t, err := time.Parse("2006-01-02", birthdate)
-	if err != nil {
-		return 0
-	}
-	now := time.Now()
-	years := now.Year() - t.Year()
-	if (now.Month() < t.Month()) || (now.Month() == t.Month() && now.Day() < t.Day()) {
-		years--
-	}
-	if years < 0 {
-		years = 0
-	}
-	return years
-}
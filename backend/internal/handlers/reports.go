@@ -0,0 +1,580 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/jackc/pgx/v5"
+
+	"matchme-backend/internal/db"
+	"matchme-backend/internal/utils"
+)
+
+const (
+	reportOpen      = "open"
+	reportInReview  = "in_review"
+	reportResolved  = "resolved"
+	reportDismissed = "dismissed"
+
+	actionDismiss       = "dismiss"
+	actionWarn          = "warn"
+	actionSuspend       = "suspend"
+	actionBan           = "ban"
+	actionDeleteContent = "delete_content"
+
+	maxReportDetailsLength = 1000
+	maxModeratorNoteLength = 1000
+	maxModeratorNameLength = 100
+	maxSuspensionDays      = 365
+)
+
+var (
+	reportCategories = []string{"harassment", "spam", "fake_profile", "inappropriate_content", "scam", "underage", "other"}
+	reportStatuses   = []string{reportOpen, reportInReview, reportResolved, reportDismissed}
+	reportActions    = []string{actionDismiss, actionWarn, actionSuspend, actionBan, actionDeleteContent}
+
+	// a user is hidden from recommendations once this many different people
+	// have unresolved reports against them; 0 turns automatic hiding off
+	autoHideReportThreshold = envInt("MATCHME_AUTO_HIDE_REPORTS", 3)
+)
+
+// SQL condition, true while the report still waits for a moderator
+const unresolvedReportCondition = `status IN ('open', 'in_review')`
+
+type report struct {
+	ID           int     `json:"id"`
+	ReporterID   *int    `json:"reporter_id"`
+	TargetID     int     `json:"target_id"`
+	ChatID       *int    `json:"chat_id"`
+	Message      *string `json:"message"`
+	Category     string  `json:"category"`
+	Details      *string `json:"details"`
+	Status       string  `json:"status"`
+	AssignedTo   *string `json:"assigned_to"`
+	Action       *string `json:"action"`
+	Note         *string `json:"resolution_note"`
+	CreatedAt    string  `json:"created_at"`
+	UpdatedAt    string  `json:"updated_at"`
+	ResolvedAt   *string `json:"resolved_at"`
+	TargetHidden bool    `json:"target_hidden"`
+	// unresolved reports against the same user, this one included
+	TargetOpenReports int `json:"target_open_reports"`
+}
+
+const reportColumns = `
+    r.id, r.reporter_id, r.target_id, r.chat_id, r.message, r.category, r.details,
+    r.status, r.assigned_to, r.action, r.resolution_note,
+    r.created_at::timestamptz, r.updated_at::timestamptz, r.resolved_at::timestamptz,
+    u.hidden_at IS NOT NULL,
+    (SELECT COUNT(*) FROM reports o WHERE o.target_id = r.target_id AND o.` + unresolvedReportCondition + `)`
+
+func scanReports(rows pgx.Rows) ([]report, error) {
+	defer rows.Close()
+	reports := []report{}
+	for rows.Next() {
+		var rep report
+		var createdAt, updatedAt time.Time
+		var resolvedAt *time.Time
+		if err := rows.Scan(&rep.ID, &rep.ReporterID, &rep.TargetID, &rep.ChatID, &rep.Message, &rep.Category, &rep.Details,
+			&rep.Status, &rep.AssignedTo, &rep.Action, &rep.Note,
+			&createdAt, &updatedAt, &resolvedAt,
+			&rep.TargetHidden, &rep.TargetOpenReports); err != nil {
+			return nil, err
+		}
+		rep.CreatedAt = createdAt.UTC().Format(time.RFC3339)
+		rep.UpdatedAt = updatedAt.UTC().Format(time.RFC3339)
+		if resolvedAt != nil {
+			s := resolvedAt.UTC().Format(time.RFC3339)
+			rep.ResolvedAt = &s
+		}
+		reports = append(reports, rep)
+	}
+	return reports, rows.Err()
+}
+
+// GET lists the viewer's own reports, POST reports a user or one of their messages
+func ReportsHandler(w http.ResponseWriter, r *http.Request) {
+	userIDStr, err := utils.ExtractUserIDFromTokenFromCookie(r)
+	if err != nil {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+	userID, _ := strconv.Atoi(userIDStr)
+
+	switch r.Method {
+	case http.MethodGet:
+		listOwnReports(w, userID)
+	case http.MethodPost:
+		createReport(w, r, userID)
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+// reporters see what they reported and its outcome, not the moderator's notes
+func listOwnReports(w http.ResponseWriter, userID int) {
+	rows, err := db.Pool.Query(context.Background(), `
+        SELECT id, target_id, chat_id, category, status, created_at::timestamptz, resolved_at::timestamptz
+        FROM reports
+        WHERE reporter_id = $1
+        ORDER BY created_at DESC, id DESC
+    `, userID)
+	if err != nil {
+		log.Printf("Error fetching reports: %v\n", err)
+		http.Error(w, "Error retrieving reports", http.StatusInternalServerError)
+		return
+	}
+	defer rows.Close()
+
+	reports := []map[string]interface{}{}
+	for rows.Next() {
+		var id, targetID int
+		var chatID *int
+		var category, status string
+		var createdAt time.Time
+		var resolvedAt *time.Time
+		if err := rows.Scan(&id, &targetID, &chatID, &category, &status, &createdAt, &resolvedAt); err != nil {
+			log.Printf("Error scanning report: %v\n", err)
+			http.Error(w, "Error retrieving reports", http.StatusInternalServerError)
+			return
+		}
+		rep := map[string]interface{}{
+			"id":          id,
+			"target_id":   targetID,
+			"chat_id":     chatID,
+			"category":    category,
+			"status":      status,
+			"created_at":  createdAt.UTC().Format(time.RFC3339),
+			"resolved_at": nil,
+		}
+		if resolvedAt != nil {
+			rep["resolved_at"] = resolvedAt.UTC().Format(time.RFC3339)
+		}
+		reports = append(reports, rep)
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(reports)
+}
+
+func createReport(w http.ResponseWriter, r *http.Request, userID int) {
+	var body struct {
+		TargetUserID int    `json:"targetUserId"`
+		ChatID       *int   `json:"chatMessageId"` // optional, a message the target sent the reporter
+		Category     string `json:"category"`
+		Details      string `json:"details"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		http.Error(w, "Invalid request payload", http.StatusBadRequest)
+		return
+	}
+
+	errs := ValidationErrors{}
+	if body.TargetUserID == userID {
+		errs.add("targetUserId", "cannot report yourself")
+	}
+	body.Category = strings.ToLower(strings.TrimSpace(body.Category))
+	if !containsFold(reportCategories, body.Category) {
+		errs.add("category", "must be one of %s", strings.Join(reportCategories, ", "))
+	}
+	body.Details = strings.TrimSpace(body.Details)
+	checkLength(errs, "details", body.Details, maxReportDetailsLength)
+	if len(errs) > 0 {
+		writeValidationErrors(w, errs)
+		return
+	}
+
+	ctx := context.Background()
+	var exists bool
+	if err := db.Pool.QueryRow(ctx, `SELECT EXISTS (SELECT 1 FROM users WHERE id = $1)`, body.TargetUserID).Scan(&exists); err != nil {
+		log.Printf("Error checking reported user: %v\n", err)
+		http.Error(w, "Error creating report", http.StatusInternalServerError)
+		return
+	}
+	if !exists {
+		http.Error(w, "User not found", http.StatusNotFound)
+		return
+	}
+
+	// only messages the target sent to the reporter can be reported
+	var message *string
+	if body.ChatID != nil {
+		err := db.Pool.QueryRow(ctx, `
+            SELECT message FROM chats
+            WHERE id = $1 AND sender_id = $2 AND receiver_id = $3
+        `, *body.ChatID, body.TargetUserID, userID).Scan(&message)
+		if err == pgx.ErrNoRows {
+			http.Error(w, "Message not found", http.StatusNotFound)
+			return
+		}
+		if err != nil {
+			log.Printf("Error fetching reported message: %v\n", err)
+			http.Error(w, "Error creating report", http.StatusInternalServerError)
+			return
+		}
+	}
+
+	tx, err := db.Pool.Begin(ctx)
+	if err != nil {
+		http.Error(w, "Error creating report", http.StatusInternalServerError)
+		return
+	}
+	defer tx.Rollback(ctx)
+
+	var reportID int
+	err = tx.QueryRow(ctx, `
+        INSERT INTO reports (reporter_id, target_id, chat_id, message, category, details)
+        VALUES ($1, $2, $3, $4, $5, NULLIF($6, ''))
+        ON CONFLICT (reporter_id, target_id) WHERE `+unresolvedReportCondition+` DO NOTHING
+        RETURNING id
+    `, userID, body.TargetUserID, body.ChatID, message, body.Category, body.Details).Scan(&reportID)
+	if err == pgx.ErrNoRows {
+		http.Error(w, "You already have an open report about this user", http.StatusConflict)
+		return
+	}
+	if err != nil {
+		log.Printf("Error creating report: %v\n", err)
+		http.Error(w, "Error creating report", http.StatusInternalServerError)
+		return
+	}
+
+	if autoHideReportThreshold > 0 {
+		_, err = tx.Exec(ctx, `
+            UPDATE users SET hidden_at = NOW()
+            WHERE id = $1 AND hidden_at IS NULL
+              AND (SELECT COUNT(DISTINCT reporter_id) FROM reports
+                   WHERE target_id = $1 AND `+unresolvedReportCondition+`) >= $2
+        `, body.TargetUserID, autoHideReportThreshold)
+		if err != nil {
+			log.Printf("Error hiding reported user: %v\n", err)
+			http.Error(w, "Error creating report", http.StatusInternalServerError)
+			return
+		}
+	}
+	if err := tx.Commit(ctx); err != nil {
+		log.Printf("Error committing report: %v\n", err)
+		http.Error(w, "Error creating report", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"id":      reportID,
+		"message": "Report submitted successfully",
+	})
+}
+
+// GET /admin/reports?status=open&assigned_to=name lists the moderation
+// queue, oldest first
+func AdminReportsHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Header.Get("X-Admin-Secret") != adminSecret {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	query := r.URL.Query()
+	status := query.Get("status")
+	if status != "" && !containsFold(reportStatuses, status) {
+		http.Error(w, "Invalid status", http.StatusBadRequest)
+		return
+	}
+	rows, err := db.Pool.Query(context.Background(), `
+        SELECT `+reportColumns+`
+        FROM reports r
+        JOIN users u ON u.id = r.target_id
+        WHERE ($1 = '' OR r.status = $1)
+          AND ($2 = '' OR r.assigned_to = $2)
+        ORDER BY r.created_at, r.id
+    `, strings.ToLower(status), query.Get("assigned_to"))
+	if err != nil {
+		log.Printf("Error fetching report queue: %v\n", err)
+		http.Error(w, "Error retrieving reports", http.StatusInternalServerError)
+		return
+	}
+	reports, err := scanReports(rows)
+	if err != nil {
+		log.Printf("Error scanning report queue: %v\n", err)
+		http.Error(w, "Error retrieving reports", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(reports)
+}
+
+// POST /admin/reports/{id}/assign takes a report into review,
+// POST /admin/reports/{id}/resolve closes it with an action
+func AdminReportHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Header.Get("X-Admin-Secret") != adminSecret {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	pathRegex := regexp.MustCompile(`^/admin/reports/(\d+)/(assign|resolve)$`)
+	matches := pathRegex.FindStringSubmatch(r.URL.Path)
+	if matches == nil {
+		http.Error(w, "Invalid URL", http.StatusBadRequest)
+		return
+	}
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	reportID, _ := strconv.Atoi(matches[1])
+
+	if matches[2] == "assign" {
+		assignReport(w, r, reportID)
+	} else {
+		resolveReport(w, r, reportID)
+	}
+}
+
+// assigns an unresolved report to a moderator, who may take it over from another
+func assignReport(w http.ResponseWriter, r *http.Request, reportID int) {
+	var body struct {
+		Moderator string `json:"moderator"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		http.Error(w, "Invalid request payload", http.StatusBadRequest)
+		return
+	}
+	body.Moderator = strings.TrimSpace(body.Moderator)
+	if body.Moderator == "" || utf8.RuneCountInString(body.Moderator) > maxModeratorNameLength {
+		errs := ValidationErrors{}
+		errs.add("moderator", "must be 1-%d characters", maxModeratorNameLength)
+		writeValidationErrors(w, errs)
+		return
+	}
+
+	tag, err := db.Pool.Exec(context.Background(), `
+        UPDATE reports
+        SET status = $1, assigned_to = $2, updated_at = NOW()
+        WHERE id = $3 AND `+unresolvedReportCondition+`
+    `, reportInReview, body.Moderator, reportID)
+	if err != nil {
+		log.Printf("Error assigning report: %v\n", err)
+		http.Error(w, "Error assigning report", http.StatusInternalServerError)
+		return
+	}
+	if tag.RowsAffected() == 0 {
+		writeClosedReportError(w, reportID)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]string{
+		"message": "Report assigned successfully",
+	})
+}
+
+// 404 for an unknown report, 409 for one that was already closed
+func writeClosedReportError(w http.ResponseWriter, reportID int) {
+	var exists bool
+	db.Pool.QueryRow(context.Background(), `SELECT EXISTS (SELECT 1 FROM reports WHERE id = $1)`, reportID).Scan(&exists)
+	if !exists {
+		http.Error(w, "Report not found", http.StatusNotFound)
+		return
+	}
+	http.Error(w, "Report is already closed", http.StatusConflict)
+}
+
+// closes a report with the moderator's action, applies it to the reported
+// user and tells both sides; the note stays internal, the reason is what the
+// reported user is told
+func resolveReport(w http.ResponseWriter, r *http.Request, reportID int) {
+	var body struct {
+		Action      string `json:"action"`
+		Note        string `json:"note"`
+		Reason      string `json:"reason"`
+		SuspendDays int    `json:"suspendDays"`
+		Moderator   string `json:"moderator"` // defaults to the assignee
+	}
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		http.Error(w, "Invalid request payload", http.StatusBadRequest)
+		return
+	}
+
+	errs := ValidationErrors{}
+	body.Action = strings.ToLower(strings.TrimSpace(body.Action))
+	if !containsFold(reportActions, body.Action) {
+		errs.add("action", "must be one of %s", strings.Join(reportActions, ", "))
+	}
+	if body.Action == actionSuspend && (body.SuspendDays < 1 || body.SuspendDays > maxSuspensionDays) {
+		errs.add("suspendDays", "must be 1-%d for a suspension", maxSuspensionDays)
+	}
+	body.Note = strings.TrimSpace(body.Note)
+	checkLength(errs, "note", body.Note, maxModeratorNoteLength)
+	body.Reason = strings.TrimSpace(body.Reason)
+	checkLength(errs, "reason", body.Reason, maxSanctionReasonLength)
+	body.Moderator = strings.TrimSpace(body.Moderator)
+	checkLength(errs, "moderator", body.Moderator, maxModeratorNameLength)
+	if len(errs) > 0 {
+		writeValidationErrors(w, errs)
+		return
+	}
+
+	status := reportResolved
+	if body.Action == actionDismiss {
+		status = reportDismissed
+	}
+
+	ctx := context.Background()
+	tx, err := db.Pool.Begin(ctx)
+	if err != nil {
+		http.Error(w, "Error resolving report", http.StatusInternalServerError)
+		return
+	}
+	defer tx.Rollback(ctx)
+
+	var reporterID *int
+	var targetID int
+	var chatID *int
+	var moderator *string
+	err = tx.QueryRow(ctx, `
+        UPDATE reports
+        SET status = $1, action = $2, resolution_note = NULLIF($3, ''),
+            assigned_to = COALESCE(NULLIF($4, ''), assigned_to),
+            updated_at = NOW(), resolved_at = NOW()
+        WHERE id = $5 AND `+unresolvedReportCondition+`
+        RETURNING reporter_id, target_id, chat_id, assigned_to
+    `, status, body.Action, body.Note, body.Moderator, reportID).Scan(&reporterID, &targetID, &chatID, &moderator)
+	if err == pgx.ErrNoRows {
+		writeClosedReportError(w, reportID)
+		return
+	}
+	if err != nil {
+		log.Printf("Error resolving report: %v\n", err)
+		http.Error(w, "Error resolving report", http.StatusInternalServerError)
+		return
+	}
+
+	if body.Action != actionDismiss {
+		if err := applyModerationAction(tx, targetID, chatID, body.Action, body.Reason, body.SuspendDays); err != nil {
+			log.Printf("Error applying moderation action: %v\n", err)
+			http.Error(w, "Error resolving report", http.StatusInternalServerError)
+			return
+		}
+		_, err = tx.Exec(ctx, `
+            INSERT INTO moderation_actions (user_id, report_id, action, moderator, note)
+            VALUES ($1, $2, $3, $4, NULLIF($5, ''))
+        `, targetID, reportID, body.Action, moderator, body.Note)
+		if err != nil {
+			log.Printf("Error recording moderation action: %v\n", err)
+			http.Error(w, "Error resolving report", http.StatusInternalServerError)
+			return
+		}
+	}
+
+	// a user stays hidden only while enough reports are still waiting
+	_, err = tx.Exec(ctx, `
+        UPDATE users SET hidden_at = NULL
+        WHERE id = $1 AND hidden_at IS NOT NULL
+          AND ($2 = 0 OR (SELECT COUNT(DISTINCT reporter_id) FROM reports
+                          WHERE target_id = $1 AND `+unresolvedReportCondition+`) < $2)
+    `, targetID, autoHideReportThreshold)
+	if err != nil {
+		log.Printf("Error unhiding reported user: %v\n", err)
+		http.Error(w, "Error resolving report", http.StatusInternalServerError)
+		return
+	}
+	if err := tx.Commit(ctx); err != nil {
+		log.Printf("Error committing report resolution: %v\n", err)
+		http.Error(w, "Error resolving report", http.StatusInternalServerError)
+		return
+	}
+	markProfileChanged(targetID)
+	if body.Action == actionDeleteContent {
+		if err := refreshDismissalChanges(targetID); err != nil {
+			log.Printf("Error refreshing dismissals of %d: %v\n", targetID, err)
+		}
+	}
+	if body.Action == actionSuspend || body.Action == actionBan {
+		disconnectUser(targetID)
+	}
+	if message := moderationMessage(body.Action, chatID != nil, body.Reason); message != "" {
+		if err := CreateNotification(targetID, 0, "moderation_warning", message); err != nil {
+			log.Printf("Error notifying reported user: %v\n", err)
+		}
+	}
+
+	if reporterID != nil {
+		message := "Thank you for your report. We reviewed it and took action."
+		if body.Action == actionDismiss {
+			message = "Thank you for your report. We reviewed it and found no violation of our guidelines."
+		}
+		if err := CreateNotification(*reporterID, 0, "report_resolved", message); err != nil {
+			log.Printf("Error notifying reporter: %v\n", err)
+		}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]string{
+		"message": "Report resolved successfully",
+	})
+}
+
+// carries out a moderator's decision against the reported user; the reason
+// is shown to them as the suspension or ban reason
+func applyModerationAction(tx pgx.Tx, targetID int, chatID *int, action, reason string, suspendDays int) error {
+	ctx := context.Background()
+	switch action {
+	case actionWarn:
+		// the warning is sent once the resolution is committed
+		return nil
+	case actionSuspend:
+		until := time.Now().AddDate(0, 0, suspendDays)
+		return suspendUser(tx, targetID, until, reason)
+	case actionBan:
+		return banUser(tx, targetID, reason)
+	case actionDeleteContent:
+		// a reported message is removed; otherwise the profile's own text and
+		// photo are. The bio is emptied rather than unset so the profile stays
+		// complete and the user keeps access while they write a new one
+		if chatID != nil {
+			_, err := tx.Exec(ctx, `DELETE FROM chats WHERE id = $1`, *chatID)
+			return err
+		}
+		if _, err := tx.Exec(ctx, `
+            UPDATE users SET about = '', profile_picture_url = NULL WHERE id = $1
+        `, targetID); err != nil {
+			return err
+		}
+		return indexBio(tx, targetID, "")
+	}
+	return fmt.Errorf("unknown moderation action %q", action)
+}
+
+// what the reported user is told about a warning or removed content, with
+// the moderator's reason; suspended and banned users see the reason when
+// they log in instead
+func moderationMessage(action string, chatMessage bool, reason string) string {
+	var message string
+	switch action {
+	case actionWarn:
+		message = "A moderator reviewed a report about your account. Please follow our community guidelines."
+	case actionDeleteContent:
+		message = "A moderator removed your profile text and photo after a report. Please add new ones that follow our community guidelines."
+		if chatMessage {
+			message = "A moderator removed one of your messages after a report. Please follow our community guidelines."
+		}
+	default:
+		return ""
+	}
+	if reason != "" {
+		message += " Reason: " + reason
+	}
+	return message
+}
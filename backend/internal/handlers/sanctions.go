@@ -0,0 +1,219 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"log"
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+
+	"matchme-backend/internal/db"
+)
+
+const (
+	sanctionSuspension = "suspension"
+	sanctionBan        = "ban"
+
+	maxSanctionReasonLength = 500
+)
+
+// SQL condition on a row of users (qualified by alias, which may be empty)
+// that is true unless the user is banned or currently suspended
+func unsanctionedCondition(alias string) string {
+	p := ""
+	if alias != "" {
+		p = alias + "."
+	}
+	return `(` + p + `banned_at IS NULL AND (` + p + `suspended_until IS NULL OR ` + p + `suspended_until <= NOW()))`
+}
+
+// suspends the user until the given time, replacing any earlier suspension,
+// and revokes their sessions; callers close the live chat connection with
+// disconnectUser once the transaction is committed
+func suspendUser(tx pgx.Tx, userID int, until time.Time, reason string) error {
+	_, err := tx.Exec(context.Background(), `
+        UPDATE users
+        SET suspended_until = $1, suspension_reason = NULLIF($2, ''), sessions_valid_after = NOW()
+        WHERE id = $3
+    `, until, reason, userID)
+	return err
+}
+
+// bans the user for good and revokes their sessions; a repeated ban keeps
+// the original date
+func banUser(tx pgx.Tx, userID int, reason string) error {
+	_, err := tx.Exec(context.Background(), `
+        UPDATE users
+        SET banned_at = COALESCE(banned_at, NOW()), ban_reason = NULLIF($1, ''), sessions_valid_after = NOW()
+        WHERE id = $2
+    `, reason, userID)
+	return err
+}
+
+// POST /admin/users/{id}/suspension {days, reason, moderator} suspends a user,
+// POST /admin/users/{id}/ban {reason, moderator} bans one; DELETE on either
+// lifts the sanction
+func AdminUserSanctionHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Header.Get("X-Admin-Secret") != adminSecret {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	pathRegex := regexp.MustCompile(`^/admin/users/(\d+)/(suspension|ban)$`)
+	matches := pathRegex.FindStringSubmatch(r.URL.Path)
+	if matches == nil {
+		http.Error(w, "Invalid URL", http.StatusBadRequest)
+		return
+	}
+	userID, _ := strconv.Atoi(matches[1])
+
+	switch r.Method {
+	case http.MethodPost:
+		applySanction(w, r, userID, matches[2])
+	case http.MethodDelete:
+		liftSanction(w, r, userID, matches[2])
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+func applySanction(w http.ResponseWriter, r *http.Request, userID int, kind string) {
+	var body struct {
+		Days      int    `json:"days"` // suspensions only
+		Reason    string `json:"reason"`
+		Moderator string `json:"moderator"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		http.Error(w, "Invalid request payload", http.StatusBadRequest)
+		return
+	}
+
+	errs := ValidationErrors{}
+	if kind == sanctionSuspension && (body.Days < 1 || body.Days > maxSuspensionDays) {
+		errs.add("days", "must be 1-%d", maxSuspensionDays)
+	}
+	body.Reason = strings.TrimSpace(body.Reason)
+	checkLength(errs, "reason", body.Reason, maxSanctionReasonLength)
+	body.Moderator = strings.TrimSpace(body.Moderator)
+	checkLength(errs, "moderator", body.Moderator, maxModeratorNameLength)
+	if len(errs) > 0 {
+		writeValidationErrors(w, errs)
+		return
+	}
+
+	ctx := context.Background()
+	tx, err := db.Pool.Begin(ctx)
+	if err != nil {
+		http.Error(w, "Error applying sanction", http.StatusInternalServerError)
+		return
+	}
+	defer tx.Rollback(ctx)
+
+	var exists bool
+	if err := tx.QueryRow(ctx, `SELECT EXISTS (SELECT 1 FROM users WHERE id = $1)`, userID).Scan(&exists); err != nil {
+		log.Printf("Error checking sanctioned user: %v\n", err)
+		http.Error(w, "Error applying sanction", http.StatusInternalServerError)
+		return
+	}
+	if !exists {
+		http.Error(w, "User not found", http.StatusNotFound)
+		return
+	}
+
+	action := actionBan
+	if kind == sanctionSuspension {
+		action = actionSuspend
+		err = suspendUser(tx, userID, time.Now().AddDate(0, 0, body.Days), body.Reason)
+	} else {
+		err = banUser(tx, userID, body.Reason)
+	}
+	if err == nil {
+		err = recordModerationAction(tx, userID, action, body.Moderator, body.Reason)
+	}
+	if err != nil {
+		log.Printf("Error applying %s: %v\n", kind, err)
+		http.Error(w, "Error applying sanction", http.StatusInternalServerError)
+		return
+	}
+	if err := tx.Commit(ctx); err != nil {
+		log.Printf("Error committing %s: %v\n", kind, err)
+		http.Error(w, "Error applying sanction", http.StatusInternalServerError)
+		return
+	}
+	disconnectUser(userID)
+
+	message := "User suspended successfully"
+	if kind == sanctionBan {
+		message = "User banned successfully"
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]string{"message": message})
+}
+
+// lifts a suspension or ban; sessions revoked by it stay revoked, so the
+// user logs in again
+func liftSanction(w http.ResponseWriter, r *http.Request, userID int, kind string) {
+	var body struct {
+		Moderator string `json:"moderator"`
+	}
+	// the body is optional
+	json.NewDecoder(r.Body).Decode(&body)
+
+	query := `
+        UPDATE users SET banned_at = NULL, ban_reason = NULL
+        WHERE id = $1 AND banned_at IS NOT NULL`
+	action, notFound, message := "unban", "Ban not found", "User unbanned successfully"
+	if kind == sanctionSuspension {
+		query = `
+        UPDATE users SET suspended_until = NULL, suspension_reason = NULL
+        WHERE id = $1 AND suspended_until > NOW()`
+		action, notFound, message = "unsuspend", "Suspension not found", "Suspension lifted successfully"
+	}
+
+	ctx := context.Background()
+	tx, err := db.Pool.Begin(ctx)
+	if err != nil {
+		http.Error(w, "Error lifting sanction", http.StatusInternalServerError)
+		return
+	}
+	defer tx.Rollback(ctx)
+
+	tag, err := tx.Exec(ctx, query, userID)
+	if err != nil {
+		log.Printf("Error lifting %s: %v\n", kind, err)
+		http.Error(w, "Error lifting sanction", http.StatusInternalServerError)
+		return
+	}
+	if tag.RowsAffected() == 0 {
+		http.Error(w, notFound, http.StatusNotFound)
+		return
+	}
+	if err := recordModerationAction(tx, userID, action, strings.TrimSpace(body.Moderator), ""); err != nil {
+		log.Printf("Error recording moderation action: %v\n", err)
+		http.Error(w, "Error lifting sanction", http.StatusInternalServerError)
+		return
+	}
+	if err := tx.Commit(ctx); err != nil {
+		log.Printf("Error committing lifted %s: %v\n", kind, err)
+		http.Error(w, "Error lifting sanction", http.StatusInternalServerError)
+		return
+	}
+	markRecommendationsStale(userID)
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]string{"message": message})
+}
+
+// adds an action taken outside of a report to the moderation log
+func recordModerationAction(tx pgx.Tx, userID int, action, moderator, note string) error {
+	_, err := tx.Exec(context.Background(), `
+        INSERT INTO moderation_actions (user_id, action, moderator, note)
+        VALUES ($1, $2, NULLIF($3, ''), NULLIF($4, ''))
+    `, userID, action, moderator, note)
+	return err
+}
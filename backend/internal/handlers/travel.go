@@ -0,0 +1,241 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"matchme-backend/internal/db"
+	"matchme-backend/internal/models"
+	"matchme-backend/internal/utils"
+)
+
+const (
+	dateLayout = "2006-01-02"
+	// longest trip, in days including both ends
+	maxTravelDays = 90
+	// how far ahead a trip may be planned
+	maxTravelLeadDays = 365
+)
+
+// returns SQL for the country or city a users row is matched on: the travel
+// location while a trip is on, the home location otherwise
+func effectiveLocationSQL(column string) string {
+	return `(CASE WHEN travel_starts_on <= CURRENT_DATE AND travel_ends_on >= CURRENT_DATE
+                  THEN travel_` + column + ` ELSE ` + column + ` END)`
+}
+
+// reports whether the user's trip covers today
+func travelActive(u models.User) bool {
+	if u.TravelCity == nil || u.TravelStartsOn == nil || u.TravelEndsOn == nil {
+		return false
+	}
+	today := time.Now().Format(dateLayout)
+	return *u.TravelStartsOn <= today && today <= *u.TravelEndsOn
+}
+
+// replaces the home location with the travel location while a trip is on
+func useTravelLocation(u *models.User) {
+	if travelActive(*u) {
+		u.Country = u.TravelCountry
+		u.City = u.TravelCity
+	}
+}
+
+// the "visiting" note on profiles while a trip is on; the city follows the
+// owner's city visibility
+func visitingView(u models.User, showCity bool) map[string]interface{} {
+	if !travelActive(u) {
+		return nil
+	}
+	var city *string
+	if showCity {
+		city = u.TravelCity
+	}
+	return map[string]interface{}{
+		"country": u.TravelCountry,
+		"city":    city,
+		"until":   u.TravelEndsOn,
+	}
+}
+
+// GET returns the viewer's trip, PUT plans or changes it, DELETE cancels it
+func TravelHandler(w http.ResponseWriter, r *http.Request) {
+	userIDStr, err := utils.ExtractUserIDFromTokenFromCookie(r)
+	if err != nil {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+	userID, _ := strconv.Atoi(userIDStr)
+
+	switch r.Method {
+	case http.MethodGet:
+		writeTravel(w, userID)
+	case http.MethodPut:
+		updateTravel(w, r, userID)
+	case http.MethodDelete:
+		cancelTravel(w, userID)
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+func updateTravel(w http.ResponseWriter, r *http.Request, userID int) {
+	var body struct {
+		Country  string `json:"country"`
+		City     string `json:"city"`
+		StartsOn string `json:"starts_on"`
+		EndsOn   string `json:"ends_on"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		http.Error(w, "Invalid input", http.StatusBadRequest)
+		return
+	}
+
+	errs := ValidationErrors{}
+	body.Country = strings.TrimSpace(body.Country)
+	body.City = strings.TrimSpace(body.City)
+	if !isValidLocation(body.Country) {
+		errs.add("country", "must be one of %s", strings.Join(validLocations, ", "))
+	} else {
+		body.Country = canonicalOption(validLocations, body.Country)
+		if !isValidCity(body.Country, body.City) {
+			errs.add("city", "is not a city in %s", body.Country)
+		} else {
+			body.City = canonicalOption(cityOptionsMap[body.Country], body.City)
+		}
+	}
+
+	today, _ := time.Parse(dateLayout, time.Now().Format(dateLayout))
+	start, startErr := time.Parse(dateLayout, body.StartsOn)
+	end, endErr := time.Parse(dateLayout, body.EndsOn)
+	if startErr != nil {
+		errs.add("starts_on", "must be a date (YYYY-MM-DD)")
+	} else if start.After(today.AddDate(0, 0, maxTravelLeadDays)) {
+		errs.add("starts_on", "must be within %d days", maxTravelLeadDays)
+	}
+	if endErr != nil {
+		errs.add("ends_on", "must be a date (YYYY-MM-DD)")
+	} else if end.Before(today) {
+		errs.add("ends_on", "cannot be in the past")
+	}
+	if startErr == nil && endErr == nil {
+		if end.Before(start) {
+			errs.add("ends_on", "cannot be before starts_on")
+		} else if end.Sub(start).Hours()/24+1 > maxTravelDays {
+			errs.add("ends_on", "trips can last at most %d days", maxTravelDays)
+		}
+	}
+	if len(errs) > 0 {
+		writeValidationErrors(w, errs)
+		return
+	}
+
+	_, err := db.Pool.Exec(context.Background(), `
+        UPDATE users
+        SET travel_country = $1, travel_city = $2,
+            travel_starts_on = $3, travel_ends_on = $4,
+            travel_applied = ($3::date <= CURRENT_DATE)
+        WHERE id = $5
+    `, body.Country, body.City, body.StartsOn, body.EndsOn, userID)
+	if err != nil {
+		log.Printf("Error updating travel: %v\n", err)
+		http.Error(w, "Error updating travel", http.StatusInternalServerError)
+		return
+	}
+	markProfileChanged(userID)
+
+	writeTravel(w, userID)
+}
+
+func cancelTravel(w http.ResponseWriter, userID int) {
+	if _, err := db.Pool.Exec(context.Background(), clearTravelSQL+` WHERE id = $1`, userID); err != nil {
+		log.Printf("Error cancelling travel: %v\n", err)
+		http.Error(w, "Error cancelling travel", http.StatusInternalServerError)
+		return
+	}
+	markProfileChanged(userID)
+
+	writeTravel(w, userID)
+}
+
+const clearTravelSQL = `
+        UPDATE users
+        SET travel_country = NULL, travel_city = NULL,
+            travel_starts_on = NULL, travel_ends_on = NULL,
+            travel_applied = FALSE`
+
+func writeTravel(w http.ResponseWriter, userID int) {
+	var u models.User
+	err := db.Pool.QueryRow(context.Background(), `
+        SELECT travel_country, travel_city,
+               TO_CHAR(travel_starts_on, 'YYYY-MM-DD'), TO_CHAR(travel_ends_on, 'YYYY-MM-DD')
+        FROM users WHERE id = $1
+    `, userID).Scan(&u.TravelCountry, &u.TravelCity, &u.TravelStartsOn, &u.TravelEndsOn)
+	if err != nil {
+		log.Printf("Error fetching travel: %v\n", err)
+		http.Error(w, "Error retrieving travel", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(travelPlan(u))
+}
+
+// the user's own view of their trip, with nulls when none is planned
+func travelPlan(u models.User) map[string]interface{} {
+	return map[string]interface{}{
+		"country":   u.TravelCountry,
+		"city":      u.TravelCity,
+		"starts_on": u.TravelStartsOn,
+		"ends_on":   u.TravelEndsOn,
+		"active":    travelActive(u),
+	}
+}
+
+// clears trips that have ended and flags recommendations for recomputation
+// when a trip starts or ends; returns how many users were affected
+func ApplyTravelChanges() (int, error) {
+	rows, err := db.Pool.Query(context.Background(), `
+        WITH ended AS (
+            `+clearTravelSQL+`
+            WHERE travel_ends_on < CURRENT_DATE
+            RETURNING id
+        ), started AS (
+            UPDATE users
+            SET travel_applied = TRUE
+            WHERE NOT travel_applied
+              AND travel_starts_on <= CURRENT_DATE
+              AND travel_ends_on >= CURRENT_DATE
+            RETURNING id
+        )
+        SELECT id FROM ended
+        UNION
+        SELECT id FROM started
+    `)
+	if err != nil {
+		return 0, err
+	}
+	var ids []int
+	for rows.Next() {
+		var id int
+		if err := rows.Scan(&id); err != nil {
+			rows.Close()
+			return 0, err
+		}
+		ids = append(ids, id)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return 0, err
+	}
+
+	for _, id := range ids {
+		markProfileChanged(id)
+	}
+	return len(ids), nil
+}
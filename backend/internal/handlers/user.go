@@ -85,29 +85,38 @@ func UpdateProfileHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// validate age range
-	if user.LookingForMinAge != nil && user.LookingForMaxAge != nil {
-		if *user.LookingForMinAge > *user.LookingForMaxAge {
-			http.Error(w, "Min age cannot be greater than max age", http.StatusBadRequest)
-			return
-		}
+	current, err := getUserByID(userID)
+	if err != nil {
+		log.Printf("Error fetching user %d for update: %v\n", userID, err)
+		http.Error(w, "Error updating profile", http.StatusInternalServerError)
+		return
 	}
 
-	// validate country if provided
-	if user.Country != nil && *user.Country != "" {
-		if !isValidLocation(*user.Country) {
-			http.Error(w, "Invalid country specified", http.StatusBadRequest)
+	var attributeDefs map[string]models.AttributeDefinition
+	if len(user.Attributes) > 0 || len(user.AttributeFilters) > 0 {
+		attributeDefs, err = loadAttributeDefinitions()
+		if err != nil {
+			log.Printf("Error loading attribute definitions: %v\n", err)
+			http.Error(w, "Error updating profile", http.StatusInternalServerError)
 			return
 		}
 	}
 
-	// validate city if provided
-	if user.Country != nil && *user.Country != "" && user.City != nil && *user.City != "" {
-		if !isValidCity(*user.Country, *user.City) {
-			http.Error(w, "Invalid city for the specified country", http.StatusBadRequest)
-			return
-		}
+	normalizeProfileUpdate(&user)
+	errs := validateProfileUpdate(user, current)
+	validateAttributes(errs, &user, attributeDefs)
+	if len(errs) > 0 {
+		writeValidationErrors(w, errs)
+		return
+	}
+	user.UserID = userID
+
+	tx, err := db.Pool.Begin(context.Background())
+	if err != nil {
+		http.Error(w, "Error updating profile", http.StatusInternalServerError)
+		return
 	}
+	defer tx.Rollback(context.Background())
 
 	query := `
 		UPDATE users
@@ -126,10 +135,22 @@ func UpdateProfileHandler(w http.ResponseWriter, r *http.Request) {
 			profile_picture_url = COALESCE($12, profile_picture_url),
 			preferred_hobbies = COALESCE($13, preferred_hobbies),
 			preferred_interests = COALESCE($14, preferred_interests),
-			birthdate = CASE WHEN $15 != '' THEN $15::date ELSE birthdate END
-		WHERE id = $16
+			birthdate = CASE WHEN $15 != '' THEN $15::date ELSE birthdate END,
+			surname_visibility = COALESCE($16, surname_visibility),
+			birthdate_visibility = COALESCE($17, birthdate_visibility),
+			city_visibility = COALESCE($18, city_visibility),
+			photo_visibility = COALESCE($19, photo_visibility),
+			gender_strictness = COALESCE($20, gender_strictness),
+			age_strictness = COALESCE($21, age_strictness),
+			age_tolerance = COALESCE($22, age_tolerance),
+			min_shared_hobbies = COALESCE($23, min_shared_hobbies),
+			min_shared_interests = COALESCE($24, min_shared_interests),
+			shared_strictness = COALESCE($25, shared_strictness),
+			timezone = COALESCE($26, timezone),
+			timezone_changed_at = CASE WHEN COALESCE($26, timezone) <> timezone THEN NOW() ELSE timezone_changed_at END
+		WHERE id = $27
 	`
-	_, err = db.Pool.Exec(context.Background(), query,
+	_, err = tx.Exec(context.Background(), query,
 		user.Fname,
 		user.Surname,
 		user.Gender,
@@ -151,6 +172,17 @@ func UpdateProfileHandler(w http.ResponseWriter, r *http.Request) {
 			}
 			return *user.Birthdate
 		}(),
+		user.SurnameVisibility,
+		user.BirthdateVisibility,
+		user.CityVisibility,
+		user.PhotoVisibility,
+		user.GenderStrictness,
+		user.AgeStrictness,
+		user.AgeTolerance,
+		user.MinSharedHobbies,
+		user.MinSharedInterests,
+		user.SharedStrictness,
+		user.Timezone,
 		userID,
 	)
 	if err != nil {
@@ -159,9 +191,79 @@ func UpdateProfileHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	if err := saveUserAttributes(tx, user, attributeDefs); err != nil {
+		log.Printf("Error updating profile attributes: %v\n", err)
+		http.Error(w, "Error updating profile", http.StatusInternalServerError)
+		return
+	}
+
+	if user.About != nil {
+		if err := indexBio(tx, userID, *user.About); err != nil {
+			log.Printf("Error indexing bio: %v\n", err)
+			http.Error(w, "Error updating profile", http.StatusInternalServerError)
+			return
+		}
+	}
+
+	if err := tx.Commit(context.Background()); err != nil {
+		log.Printf("Error committing profile update: %v\n", err)
+		http.Error(w, "Error updating profile", http.StatusInternalServerError)
+		return
+	}
+
+	if err := utils.MarkProfileCompleted(userID); err != nil {
+		log.Printf("Error marking profile %d as completed: %v\n", userID, err)
+	}
+	markProfileChanged(userID)
+	if err := refreshDismissalChanges(userID); err != nil {
+		log.Printf("Error refreshing dismissals of %d: %v\n", userID, err)
+	}
+
 	w.WriteHeader(http.StatusNoContent)
 }
 
+// returns how much of the viewer's profile is filled in and what is missing
+func ProfileCompletenessHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	userIDStr, err := utils.ExtractUserIDFromTokenFromCookie(r)
+	if err != nil {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+	userID, _ := strconv.Atoi(userIDStr)
+
+	completeness, err := utils.GetProfileCompleteness(userID)
+	if err != nil {
+		log.Printf("Error computing profile completeness: %v\n", err)
+		http.Error(w, "Error retrieving profile completeness", http.StatusInternalServerError)
+		return
+	}
+
+	var completedAt *string
+	err = db.Pool.QueryRow(context.Background(), `
+        SELECT TO_CHAR(profile_completed_at, 'YYYY-MM-DD"T"HH24:MI:SS"Z"')
+        FROM users
+        WHERE id = $1
+    `, userID).Scan(&completedAt)
+	if err != nil {
+		log.Printf("Error fetching profile_completed_at: %v\n", err)
+		http.Error(w, "Error retrieving profile completeness", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"complete":             completeness.Complete,
+		"percentage":           completeness.Percentage,
+		"missing":              completeness.Missing,
+		"profile_completed_at": completedAt,
+	})
+}
+
 func ProfileHandler(w http.ResponseWriter, r *http.Request) {
 	http.ServeFile(w, r, "../frontend/build/index.html")
 }
@@ -207,82 +309,45 @@ func UsersHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	rel, err := getViewerRelation(viewerID, targetID)
+	if err != nil {
+		log.Printf("Error resolving viewer relation: %v\n", err)
+		http.Error(w, "Error retrieving user", http.StatusInternalServerError)
+		return
+	}
+
 	switch subPath {
-	case "profile":
-		handleUserProfile(w, user, viewerID)
-	case "bio":
-		handleUserBio(w, user)
-	case "":
-		handleUserMinimal(w, user)
+	case viewProfile:
+		resp := serializeUser(user, rel, subPath)
+		if rel != relationSelf {
+			compatibility, err := loadCompatibility(viewerID, targetID)
+			if err != nil {
+				log.Printf("Error computing compatibility: %v\n", err)
+			}
+			resp["compatibility"] = compatibility
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(resp)
+	case viewBio, viewMinimal:
+		writeUserView(w, user, rel, subPath)
 	default:
 		http.NotFound(w, r)
 	}
 }
 
-func handleUserMinimal(w http.ResponseWriter, user models.User) {
-	// return name and profile link
-	name := ""
-	if user.Fname != nil && user.Surname != nil {
-		name = fmt.Sprintf("%s %s", *user.Fname, *user.Surname)
-	}
-	resp := map[string]interface{}{
-		"id":    user.UserID,
-		"name":  name,
-		"photo": user.Picture,
-	}
-	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(resp)
-}
-
-func handleUserProfile(w http.ResponseWriter, user models.User, viewerID int) {
-	resp := map[string]interface{}{
-		"id":                  user.UserID,
-		"fname":               user.Fname,
-		"surname":             user.Surname,
-		"about":               user.About,
-		"profile_picture_url": user.Picture,
-		"gender":              user.Gender,
-		"birthdate":           user.Birthdate,
-		"hobbies":             user.Hobbies,
-		"interests":           user.Interests,
-		"country":             user.Country,
-		"city":                user.City,
-		"looking_for_gender":  user.LookingForGender,
-		"looking_for_min_age": user.LookingForMinAge,
-		"looking_for_max_age": user.LookingForMaxAge,
-		"preferred_hobbies":   user.PreferredHobbies,
-		"preferred_interests": user.PreferredInterests,
-	}
-	if viewerID == user.UserID {
-		resp["email"] = user.Email
-	}
-	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(resp)
-}
-
-func handleUserBio(w http.ResponseWriter, user models.User) {
-	resp := map[string]interface{}{
-		"id":        user.UserID,
-		"gender":    user.Gender,
-		"birthdate": user.Birthdate,
-		"hobbies":   user.Hobbies,
-		"about":     user.About,
-		"interests": user.Interests,
-		"country":   user.Country,
-		"city":      user.City,
-	}
-	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(resp)
-}
-
-// checks recommended, pending, or connected
+// checks recommended, pending, or connected, and that neither user blocked the other
 func IsUserAllowedToViewProfile(viewerID, targetID int) (bool, error) {
 	if viewerID == targetID {
 		return true, nil
 	}
 
+	blocked, err := isBlocked(viewerID, targetID)
+	if err != nil || blocked {
+		return false, err
+	}
+
 	var count int
-	err := db.Pool.QueryRow(context.Background(), `
+	err = db.Pool.QueryRow(context.Background(), `
         SELECT COUNT(*)
         FROM connections
         WHERE
@@ -297,11 +362,11 @@ func IsUserAllowedToViewProfile(viewerID, targetID int) (bool, error) {
 		return true, nil
 	}
 
-	// check if the user is in recommendations
+	// check if the user was shown to the viewer and is still in their pool
 	err = db.Pool.QueryRow(context.Background(), `
         SELECT COUNT(*)
         FROM recommendations
-        WHERE user_id = $1 AND recommended_user_id = $2
+        WHERE user_id = $1 AND recommended_user_id = $2 AND served_at IS NOT NULL
     `, viewerID, targetID).Scan(&count)
 	if err != nil {
 		return false, err
@@ -336,7 +401,23 @@ func getUserByID(userID int) (models.User, error) {
             looking_for_max_age,
             profile_picture_url,
             preferred_hobbies,
-            preferred_interests
+            preferred_interests,
+            surname_visibility,
+            birthdate_visibility,
+            city_visibility,
+            photo_visibility,
+            gender_strictness,
+            age_strictness,
+            age_tolerance,
+            min_shared_hobbies,
+            min_shared_interests,
+            shared_strictness,
+            travel_country,
+            travel_city,
+            TO_CHAR(travel_starts_on, 'YYYY-MM-DD'),
+            TO_CHAR(travel_ends_on, 'YYYY-MM-DD'),
+            timezone,
+            timezone_changed_at
         FROM users
         WHERE id = $1
     `, userID).Scan(
@@ -358,8 +439,29 @@ func getUserByID(userID int) (models.User, error) {
 		&u.Picture,
 		&u.PreferredHobbies,
 		&u.PreferredInterests,
+		&u.SurnameVisibility,
+		&u.BirthdateVisibility,
+		&u.CityVisibility,
+		&u.PhotoVisibility,
+		&u.GenderStrictness,
+		&u.AgeStrictness,
+		&u.AgeTolerance,
+		&u.MinSharedHobbies,
+		&u.MinSharedInterests,
+		&u.SharedStrictness,
+		&u.TravelCountry,
+		&u.TravelCity,
+		&u.TravelStartsOn,
+		&u.TravelEndsOn,
+		&u.Timezone,
+		&u.TimezoneChangedAt,
 	)
-	return u, err
+	if err != nil {
+		return u, err
+	}
+	users := []models.User{u}
+	err = attachAttributes(users)
+	return users[0], err
 }
 
 func isValidLocation(country string) bool {
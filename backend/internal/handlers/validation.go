@@ -0,0 +1,317 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"matchme-backend/internal/models"
+	"matchme-backend/internal/scoring"
+	"matchme-backend/internal/utils"
+)
+
+const (
+	minUserAge       = 18
+	maxUserAge       = 120
+	maxNameLength    = 100
+	maxAboutLength   = 2000
+	maxPictureURLLen = 2048
+	maxListItems     = 8
+	maxAgeTolerance  = 10
+)
+
+var lookingForGenders = []string{"male", "female", "other", "any"}
+
+var strictnessLevels = []string{scoring.StrictnessHard, scoring.StrictnessSoft}
+
+// per-field validation errors keyed by the JSON field name
+type ValidationErrors map[string]string
+
+// records the first error for a field
+func (e ValidationErrors) add(field, format string, args ...interface{}) {
+	if _, exists := e[field]; exists {
+		return
+	}
+	e[field] = fmt.Sprintf(format, args...)
+}
+
+// writes a 400 response with the per-field errors
+func writeValidationErrors(w http.ResponseWriter, errs ValidationErrors) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusBadRequest)
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"error":  "Validation failed",
+		"fields": errs,
+	})
+}
+
+// trims strings, canonicalizes enumerations and removes duplicate list items
+// so that validation and storage both see the same values
+func normalizeProfileUpdate(u *models.User) {
+	for _, s := range []*string{u.Fname, u.Surname, u.About, u.Country, u.City, u.Picture, u.Birthdate, u.Timezone} {
+		if s != nil {
+			*s = strings.TrimSpace(*s)
+		}
+	}
+	if u.Gender != nil {
+		*u.Gender = strings.ToLower(strings.TrimSpace(*u.Gender))
+	}
+	if u.LookingForGender != nil {
+		*u.LookingForGender = strings.ToLower(strings.TrimSpace(*u.LookingForGender))
+	}
+	for _, v := range []*string{u.SurnameVisibility, u.BirthdateVisibility, u.CityVisibility, u.PhotoVisibility,
+		u.GenderStrictness, u.AgeStrictness, u.SharedStrictness} {
+		if v != nil {
+			*v = strings.ToLower(strings.TrimSpace(*v))
+		}
+	}
+	if u.Country != nil {
+		*u.Country = canonicalOption(validLocations, *u.Country)
+		if u.City != nil {
+			*u.City = canonicalOption(cityOptionsMap[*u.Country], *u.City)
+		}
+	}
+	u.Hobbies = dedupeOptions(u.Hobbies, hobbiesList)
+	u.Interests = dedupeOptions(u.Interests, interestsList)
+	u.PreferredHobbies = dedupeOptions(u.PreferredHobbies, hobbiesList)
+	u.PreferredInterests = dedupeOptions(u.PreferredInterests, interestsList)
+}
+
+// validates an update against the stored profile
+// nil fields in the update keep their stored value, so cross-field rules
+// (age range ordering, preferred items) are checked on the merged result
+func validateProfileUpdate(update, current models.User) ValidationErrors {
+	errs := ValidationErrors{}
+
+	checkText(errs, "fname", update.Fname, maxNameLength)
+	checkText(errs, "surname", update.Surname, maxNameLength)
+	checkText(errs, "about", update.About, maxAboutLength)
+	if update.Picture != nil && utf8.RuneCountInString(*update.Picture) > maxPictureURLLen {
+		errs.add("profile_picture_url", "must be at most %d characters", maxPictureURLLen)
+	}
+
+	if update.Gender != nil && !containsFold(genders, *update.Gender) {
+		errs.add("gender", "must be one of %s", strings.Join(genders, ", "))
+	}
+	if update.LookingForGender != nil && !containsFold(lookingForGenders, *update.LookingForGender) {
+		errs.add("looking_for_gender", "must be one of %s", strings.Join(lookingForGenders, ", "))
+	}
+
+	checkVisibility(errs, "surname_visibility", update.SurnameVisibility)
+	checkVisibility(errs, "birthdate_visibility", update.BirthdateVisibility)
+	checkVisibility(errs, "city_visibility", update.CityVisibility)
+	checkVisibility(errs, "photo_visibility", update.PhotoVisibility)
+
+	if update.Birthdate != nil && *update.Birthdate != "" {
+		checkBirthdate(errs, *update.Birthdate)
+	}
+
+	// location: a city is only meaningful together with its country
+	country := pick(update.Country, current.Country)
+	if update.Country != nil && *update.Country != "" && !isValidLocation(*update.Country) {
+		errs.add("country", "invalid country specified")
+	}
+	if update.City != nil && *update.City != "" {
+		if country == nil || *country == "" {
+			errs.add("city", "country is required to set a city")
+		} else if !isValidCity(*country, *update.City) {
+			errs.add("city", "invalid city for the specified country")
+		}
+	} else if update.Country != nil && current.City != nil && !isValidCity(*update.Country, *current.City) {
+		errs.add("city", "city must be updated when the country changes")
+	}
+
+	minAge := pickInt(update.LookingForMinAge, current.LookingForMinAge)
+	maxAge := pickInt(update.LookingForMaxAge, current.LookingForMaxAge)
+	if update.LookingForMinAge != nil && (*minAge < minUserAge || *minAge > maxUserAge) {
+		errs.add("looking_for_min_age", "must be between %d and %d", minUserAge, maxUserAge)
+	}
+	if update.LookingForMaxAge != nil && (*maxAge < minUserAge || *maxAge > maxUserAge) {
+		errs.add("looking_for_max_age", "must be between %d and %d", minUserAge, maxUserAge)
+	}
+	if minAge != nil && maxAge != nil && *minAge > *maxAge {
+		errs.add("looking_for_min_age", "min age cannot be greater than max age")
+	}
+
+	if update.Timezone != nil {
+		if _, err := time.LoadLocation(*update.Timezone); err != nil || *update.Timezone == "" || *update.Timezone == "Local" {
+			errs.add("timezone", "must be an IANA time zone such as Europe/Tallinn")
+		} else if current.Timezone != nil && *update.Timezone != *current.Timezone && current.TimezoneChangedAt != nil &&
+			time.Since(*current.TimezoneChangedAt) < timezoneChangeInterval {
+			// moving midnight around would reset the like quotas early
+			errs.add("timezone", "can be changed once every %d days", int(timezoneChangeInterval.Hours()/24))
+		}
+	}
+
+	checkStrictness(errs, "gender_strictness", update.GenderStrictness)
+	checkStrictness(errs, "age_strictness", update.AgeStrictness)
+	checkStrictness(errs, "shared_strictness", update.SharedStrictness)
+	checkRange(errs, "age_tolerance", update.AgeTolerance, 0, maxAgeTolerance)
+	checkRange(errs, "min_shared_hobbies", update.MinSharedHobbies, 0, maxListItems)
+	checkRange(errs, "min_shared_interests", update.MinSharedInterests, 0, maxListItems)
+
+	checkOptions(errs, "hobbies", update.Hobbies, hobbiesList)
+	checkOptions(errs, "interests", update.Interests, interestsList)
+	checkOptions(errs, "preferred_hobbies", update.PreferredHobbies, hobbiesList)
+	checkOptions(errs, "preferred_interests", update.PreferredInterests, interestsList)
+
+	// preferred items must be a subset of the user's own lists
+	checkSubset(errs, "preferred_hobbies",
+		pickList(update.PreferredHobbies, current.PreferredHobbies),
+		pickList(update.Hobbies, current.Hobbies), "hobbies")
+	checkSubset(errs, "preferred_interests",
+		pickList(update.PreferredInterests, current.PreferredInterests),
+		pickList(update.Interests, current.Interests), "interests")
+
+	return errs
+}
+
+func checkText(errs ValidationErrors, field string, value *string, maxLen int) {
+	if value == nil {
+		return
+	}
+	if *value == "" {
+		errs.add(field, "must not be empty")
+		return
+	}
+	if utf8.RuneCountInString(*value) > maxLen {
+		errs.add(field, "must be at most %d characters", maxLen)
+	}
+}
+
+// like checkText for optional free text, where empty is fine
+func checkLength(errs ValidationErrors, field, value string, maxLen int) {
+	if utf8.RuneCountInString(value) > maxLen {
+		errs.add(field, "must be at most %d characters", maxLen)
+	}
+}
+
+func checkVisibility(errs ValidationErrors, field string, value *string) {
+	if value != nil && !containsFold(visibilityLevels, *value) {
+		errs.add(field, "must be one of %s", strings.Join(visibilityLevels, ", "))
+	}
+}
+
+func checkStrictness(errs ValidationErrors, field string, value *string) {
+	if value != nil && !containsFold(strictnessLevels, *value) {
+		errs.add(field, "must be one of %s", strings.Join(strictnessLevels, ", "))
+	}
+}
+
+func checkRange(errs ValidationErrors, field string, value *int, min, max int) {
+	if value != nil && (*value < min || *value > max) {
+		errs.add(field, "must be between %d and %d", min, max)
+	}
+}
+
+func checkBirthdate(errs ValidationErrors, birthdate string) {
+	t, err := time.Parse("2006-01-02", birthdate)
+	if err != nil {
+		errs.add("birthdate", "must be a date in YYYY-MM-DD format")
+		return
+	}
+	if t.After(time.Now()) {
+		errs.add("birthdate", "must not be in the future")
+		return
+	}
+	age := utils.CalcAge(birthdate)
+	if age < minUserAge {
+		errs.add("birthdate", "you must be at least %d years old", minUserAge)
+	} else if age > maxUserAge {
+		errs.add("birthdate", "age cannot exceed %d years", maxUserAge)
+	}
+}
+
+func checkOptions(errs ValidationErrors, field string, values *[]string, allowed []string) {
+	if values == nil {
+		return
+	}
+	if len(*values) > maxListItems {
+		errs.add(field, "must contain at most %d items", maxListItems)
+		return
+	}
+	for _, v := range *values {
+		if !containsFold(allowed, v) {
+			errs.add(field, "unknown value %q", v)
+			return
+		}
+	}
+}
+
+func checkSubset(errs ValidationErrors, field string, subset, superset *[]string, supersetField string) {
+	if subset == nil {
+		return
+	}
+	var own []string
+	if superset != nil {
+		own = *superset
+	}
+	for _, v := range *subset {
+		if !containsFold(own, v) {
+			errs.add(field, "%q is not one of your %s", v, supersetField)
+			return
+		}
+	}
+}
+
+// returns the option spelled as in the allowed list, or the input unchanged
+func canonicalOption(allowed []string, value string) string {
+	for _, a := range allowed {
+		if strings.EqualFold(a, value) {
+			return a
+		}
+	}
+	return value
+}
+
+// canonicalizes and removes case-insensitive duplicates, keeping the first occurrence
+func dedupeOptions(values *[]string, allowed []string) *[]string {
+	if values == nil {
+		return nil
+	}
+	seen := make(map[string]bool)
+	result := make([]string, 0, len(*values))
+	for _, v := range *values {
+		v = canonicalOption(allowed, strings.TrimSpace(v))
+		key := strings.ToLower(v)
+		if seen[key] {
+			continue
+		}
+		seen[key] = true
+		result = append(result, v)
+	}
+	return &result
+}
+
+func containsFold(list []string, value string) bool {
+	for _, item := range list {
+		if strings.EqualFold(item, value) {
+			return true
+		}
+	}
+	return false
+}
+
+func pick(update, current *string) *string {
+	if update != nil {
+		return update
+	}
+	return current
+}
+
+func pickInt(update, current *int) *int {
+	if update != nil {
+		return update
+	}
+	return current
+}
+
+func pickList(update, current *[]string) *[]string {
+	if update != nil {
+		return update
+	}
+	return current
+}
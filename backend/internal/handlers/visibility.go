@@ -0,0 +1,131 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"matchme-backend/internal/db"
+	"matchme-backend/internal/utils"
+)
+
+const (
+	accountActive    = "active"
+	accountPaused    = "paused"
+	accountIncognito = "incognito"
+)
+
+var accountVisibilities = []string{accountActive, accountPaused, accountIncognito}
+
+// returns an SQL condition on a candidate row of users (qualified by alias,
+// which may be empty) that keeps only accounts the viewer ($1) may be
+// recommended: active ones, paused ones whose pause has already ended, and
+// incognito ones that sent the viewer a request; users hidden after being
+// reported and suspended or banned users are left out
+func visibleCandidateCondition(alias string) string {
+	p := ""
+	if alias != "" {
+		p = alias + "."
+	}
+	return `(` + p + `hidden_at IS NULL AND ` + unsanctionedCondition(alias) + ` AND (
+    ` + p + `visibility = 'active'
+    OR (` + p + `visibility = 'paused' AND ` + p + `paused_until IS NOT NULL AND ` + p + `paused_until <= NOW())
+    OR (` + p + `visibility = 'incognito' AND ` + p + `id IN (
+        SELECT user_id FROM connections WHERE connected_user_id = $1
+    ))
+))`
+}
+
+// GET returns the viewer's visibility state, PUT changes it
+func VisibilityHandler(w http.ResponseWriter, r *http.Request) {
+	userIDStr, err := utils.ExtractUserIDFromTokenFromCookie(r)
+	if err != nil {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+	userID, _ := strconv.Atoi(userIDStr)
+
+	switch r.Method {
+	case http.MethodGet:
+		writeVisibility(w, userID)
+	case http.MethodPut:
+		updateVisibility(w, r, userID)
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+func updateVisibility(w http.ResponseWriter, r *http.Request, userID int) {
+	var body struct {
+		Visibility  string  `json:"visibility"`
+		PausedUntil *string `json:"paused_until"` // RFC 3339, omitted for an indefinite pause
+	}
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		http.Error(w, "Invalid input", http.StatusBadRequest)
+		return
+	}
+
+	errs := ValidationErrors{}
+	body.Visibility = strings.ToLower(strings.TrimSpace(body.Visibility))
+	if !containsFold(accountVisibilities, body.Visibility) {
+		errs.add("visibility", "must be one of %s", strings.Join(accountVisibilities, ", "))
+	}
+
+	var pausedUntil *time.Time
+	if body.PausedUntil != nil && *body.PausedUntil != "" {
+		if body.Visibility != accountPaused {
+			errs.add("paused_until", "only allowed when visibility is %s", accountPaused)
+		} else if t, err := time.Parse(time.RFC3339, *body.PausedUntil); err != nil {
+			errs.add("paused_until", "must be an RFC 3339 timestamp")
+		} else if !t.After(time.Now()) {
+			errs.add("paused_until", "must be in the future")
+		} else {
+			pausedUntil = &t
+		}
+	}
+	if len(errs) > 0 {
+		writeValidationErrors(w, errs)
+		return
+	}
+
+	_, err := db.Pool.Exec(context.Background(), `
+        UPDATE users
+        SET visibility = $1, paused_until = $2
+        WHERE id = $3
+    `, body.Visibility, pausedUntil, userID)
+	if err != nil {
+		log.Printf("Error updating visibility: %v\n", err)
+		http.Error(w, "Error updating visibility", http.StatusInternalServerError)
+		return
+	}
+	markProfileChanged(userID)
+
+	writeVisibility(w, userID)
+}
+
+func writeVisibility(w http.ResponseWriter, userID int) {
+	var visibility string
+	var pausedUntil *time.Time
+	err := db.Pool.QueryRow(context.Background(), `
+        SELECT visibility, paused_until FROM users WHERE id = $1
+    `, userID).Scan(&visibility, &pausedUntil)
+	if err != nil {
+		log.Printf("Error fetching visibility: %v\n", err)
+		http.Error(w, "Error retrieving visibility", http.StatusInternalServerError)
+		return
+	}
+
+	resp := map[string]interface{}{
+		"visibility":   visibility,
+		"paused_until": nil,
+	}
+	if pausedUntil != nil {
+		resp["paused_until"] = pausedUntil.UTC().Format(time.RFC3339)
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(resp)
+}
@@ -0,0 +1,29 @@
+package jobs
+
+import (
+	"log"
+	"time"
+
+	"matchme-backend/internal/handlers"
+)
+
+// bios indexed per tick
+const bioIndexBatchSize = 500
+
+// periodically indexes bios of users that were never indexed, such as
+// accounts created before the index existed or loaded by the admin tools
+func StartBioIndexWorker(interval time.Duration) {
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			n, err := handlers.IndexMissingBios(bioIndexBatchSize)
+			if err != nil {
+				log.Printf("Error indexing bios: %v\n", err)
+			} else if n > 0 {
+				log.Printf("Indexed %d bio(s)\n", n)
+			}
+			<-ticker.C
+		}
+	}()
+}
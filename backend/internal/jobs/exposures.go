@@ -0,0 +1,34 @@
+package jobs
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"matchme-backend/internal/db"
+)
+
+// periodically deletes exposure counts from before yesterday; only today's count is capped
+func StartExposurePruneWorker(interval time.Duration) {
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			pruneExposures()
+			<-ticker.C
+		}
+	}()
+}
+
+func pruneExposures() {
+	tag, err := db.Pool.Exec(context.Background(), `
+        DELETE FROM recommendation_exposures WHERE day < CURRENT_DATE - 1
+    `)
+	if err != nil {
+		log.Printf("Error pruning recommendation exposures: %v\n", err)
+		return
+	}
+	if n := tag.RowsAffected(); n > 0 {
+		log.Printf("Pruned %d recommendation exposure(s)\n", n)
+	}
+}
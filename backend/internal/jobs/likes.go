@@ -0,0 +1,35 @@
+package jobs
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"matchme-backend/internal/db"
+)
+
+// periodically deletes sent likes from before yesterday; only the current
+// local day counts towards the quotas, and no time zone is a full day ahead
+func StartSentLikesPruneWorker(interval time.Duration) {
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			pruneSentLikes()
+			<-ticker.C
+		}
+	}()
+}
+
+func pruneSentLikes() {
+	tag, err := db.Pool.Exec(context.Background(), `
+        DELETE FROM sent_likes WHERE sent_at < NOW() - INTERVAL '2 days'
+    `)
+	if err != nil {
+		log.Printf("Error pruning sent likes: %v\n", err)
+		return
+	}
+	if n := tag.RowsAffected(); n > 0 {
+		log.Printf("Pruned %d sent like(s)\n", n)
+	}
+}
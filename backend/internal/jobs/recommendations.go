@@ -0,0 +1,40 @@
+package jobs
+
+import (
+	"log"
+	"time"
+
+	"matchme-backend/internal/handlers"
+)
+
+// users recomputed per tick, so one tick cannot hog the database
+const recommendationBatchSize = 100
+
+// periodically recomputes recommendations that are missing, flagged stale
+// (e.g. after a profile change) or older than maxAge
+func StartRecommendationWorker(interval, maxAge time.Duration) {
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			recomputeStaleRecommendations(maxAge)
+			<-ticker.C
+		}
+	}()
+}
+
+func recomputeStaleRecommendations(maxAge time.Duration) {
+	ids, err := handlers.StaleRecommendationUsers(recommendationBatchSize, maxAge)
+	if err != nil {
+		log.Printf("Error finding stale recommendations: %v\n", err)
+		return
+	}
+	for _, id := range ids {
+		if err := handlers.RecomputeRecommendations(id); err != nil {
+			log.Printf("Error recomputing recommendations for %d: %v\n", id, err)
+		}
+	}
+	if len(ids) > 0 {
+		log.Printf("Recomputed recommendations for %d user(s)\n", len(ids))
+	}
+}
@@ -0,0 +1,25 @@
+package jobs
+
+import (
+	"log"
+	"time"
+
+	"matchme-backend/internal/handlers"
+)
+
+// periodically rebuilds user-user similarity for the collaborative signal
+func StartSimilarityWorker(interval time.Duration) {
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			n, err := handlers.ComputeUserSimilarities()
+			if err != nil {
+				log.Printf("Error computing user similarities: %v\n", err)
+			} else {
+				log.Printf("Computed similar users for %d user(s)\n", n)
+			}
+			<-ticker.C
+		}
+	}()
+}
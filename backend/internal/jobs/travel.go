@@ -0,0 +1,26 @@
+package jobs
+
+import (
+	"log"
+	"time"
+
+	"matchme-backend/internal/handlers"
+)
+
+// periodically switches recommendations to a travel location when a trip
+// starts and back to the home location when it ends
+func StartTravelWorker(interval time.Duration) {
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			n, err := handlers.ApplyTravelChanges()
+			if err != nil {
+				log.Printf("Error applying travel changes: %v\n", err)
+			} else if n > 0 {
+				log.Printf("Applied travel changes for %d user(s)\n", n)
+			}
+			<-ticker.C
+		}
+	}()
+}
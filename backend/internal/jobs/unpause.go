@@ -0,0 +1,38 @@
+package jobs
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"matchme-backend/internal/db"
+)
+
+// periodically returns paused accounts whose pause has ended to active
+func StartUnpauseWorker(interval time.Duration) {
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			unpauseExpiredAccounts()
+			<-ticker.C
+		}
+	}()
+}
+
+func unpauseExpiredAccounts() {
+	tag, err := db.Pool.Exec(context.Background(), `
+        UPDATE users
+        SET visibility = 'active', paused_until = NULL
+        WHERE visibility = 'paused'
+          AND paused_until IS NOT NULL
+          AND paused_until <= NOW()
+    `)
+	if err != nil {
+		log.Printf("Error un-pausing accounts: %v\n", err)
+		return
+	}
+	if n := tag.RowsAffected(); n > 0 {
+		log.Printf("Un-paused %d account(s)\n", n)
+	}
+}
@@ -1,63 +1,66 @@
 package middleware
 
 import (
-	"context"
-	"matchme-backend/internal/db"
+	"encoding/json"
+	"errors"
 	"matchme-backend/internal/utils"
 	"net/http"
-	"strconv"
 )
 
+// authenticates the request, answering it when the token is missing or
+// revoked or the user is suspended or banned; ok reports whether to go on
+func authenticate(w http.ResponseWriter, r *http.Request) (userID int, ok bool) {
+	userID, sanction, err := utils.AuthenticateRequest(r)
+	if err != nil {
+		if errors.Is(err, utils.ErrSessionRevoked) {
+			utils.ClearTokenCookie(w)
+		}
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return 0, false
+	}
+	if sanction != nil {
+		utils.ClearTokenCookie(w)
+		utils.WriteSanction(w, sanction)
+		return 0, false
+	}
+	return userID, true
+}
+
 // ensures the user has a "complete" profile before proceeding
 func RequireCompleteProfile(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		userIDStr, err := utils.ExtractUserIDFromTokenFromCookie(r)
-		if err != nil {
-			http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		userID, ok := authenticate(w, r)
+		if !ok {
 			return
 		}
 
-		userID, err := strconv.Atoi(userIDStr)
-		if err != nil {
-			http.Error(w, "Invalid user ID", http.StatusUnauthorized)
-			return
-		}
-
-		var isComplete bool
-		err = db.Pool.QueryRow(context.Background(), `
-            SELECT COUNT(*) = 1
-            FROM users
-            WHERE id = $1
-              AND fname IS NOT NULL
-              AND surname IS NOT NULL
-              AND gender IS NOT NULL
-              AND birthdate IS NOT NULL
-              AND hobbies IS NOT NULL
-              AND about IS NOT NULL
-              AND interests IS NOT NULL
-              AND country IS NOT NULL
-              AND city IS NOT NULL
-              AND looking_for_gender IS NOT NULL
-              AND looking_for_min_age IS NOT NULL
-              AND looking_for_max_age IS NOT NULL
-        `, userID).Scan(&isComplete)
-
-		if err != nil || !isComplete {
-			http.Error(w, "Profile incomplete. Please complete your profile.", http.StatusForbidden)
+		completeness, err := utils.GetProfileCompleteness(userID)
+		if err != nil || !completeness.Complete {
+			missing := completeness.Missing
+			if missing == nil {
+				missing = []string{}
+			}
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusForbidden)
+			json.NewEncoder(w).Encode(map[string]interface{}{
+				"error":   "Profile incomplete. Please complete your profile.",
+				"missing": missing,
+			})
 			return
 		}
 
+		utils.TouchLastActive(userID)
 		next.ServeHTTP(w, r)
 	})
 }
 
 func RequireAuth(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		_, err := utils.ExtractUserIDFromTokenFromCookie(r)
-		if err != nil {
-			http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		userID, ok := authenticate(w, r)
+		if !ok {
 			return
 		}
+		utils.TouchLastActive(userID)
 		next.ServeHTTP(w, r)
 	})
 }
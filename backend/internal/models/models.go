@@ -1,5 +1,7 @@
 package models
 
+import "time"
+
 type User struct {
 	UserID             int       `json:"user_id"`
 	Email              string    `json:"email"`
@@ -19,6 +21,91 @@ type User struct {
 	Picture            *string   `json:"profile_picture_url"`
 	PreferredHobbies   *[]string `json:"preferred_hobbies"`
 	PreferredInterests *[]string `json:"preferred_interests"`
+
+	// how strictly partner preferences apply: "hard" excludes candidates who
+	// miss them, "soft" only lowers their score; unset means hard
+	GenderStrictness *string `json:"gender_strictness"`
+	AgeStrictness    *string `json:"age_strictness"`
+	// years outside the age range that are still acceptable, with a lower score
+	AgeTolerance *int `json:"age_tolerance"`
+	// hobbies and interests a partner must share; SharedStrictness applies to both
+	MinSharedHobbies   *int    `json:"min_shared_hobbies"`
+	MinSharedInterests *int    `json:"min_shared_interests"`
+	SharedStrictness   *string `json:"shared_strictness"`
+
+	// IANA time zone name, e.g. "Europe/Tallinn"; daily quotas reset at local midnight
+	Timezone *string `json:"timezone"`
+	// when Timezone last changed; it may only change every few days
+	TimezoneChangedAt *time.Time `json:"-"`
+
+	// a trip during which recommendations use this location instead of the
+	// home one; dates are YYYY-MM-DD and inclusive
+	TravelCountry  *string `json:"travel_country"`
+	TravelCity     *string `json:"travel_city"`
+	TravelStartsOn *string `json:"travel_starts_on"`
+	TravelEndsOn   *string `json:"travel_ends_on"`
+
+	// per-field visibility: "everyone", "connections" or "nobody"
+	SurnameVisibility   *string `json:"surname_visibility"`
+	BirthdateVisibility *string `json:"birthdate_visibility"`
+	CityVisibility      *string `json:"city_visibility"`
+	PhotoVisibility     *string `json:"photo_visibility"`
+
+	// values of registry-defined attributes, keyed by attribute name
+	Attributes map[string]interface{} `json:"attributes,omitempty"`
+	// what the user wants from a partner, keyed by attribute name
+	AttributeFilters map[string]*AttributeFilter `json:"attribute_filters,omitempty"`
+
+	// most recent activity, only loaded for recommendation candidates
+	LastActiveAt *time.Time `json:"-"`
+	// when the profile first became complete, only loaded for recommendation candidates
+	ProfileCompletedAt *time.Time `json:"-"`
+	// predicted affinity in [-1, 1] from similar users' likes and dismissals,
+	// keyed by target user ID; only loaded while scoring recommendations
+	Affinities map[int]float64 `json:"-"`
+	// TF-IDF weights of the terms in About; only loaded while scoring recommendations
+	BioVector map[string]float64 `json:"-"`
+	// questionnaire answers keyed by question ID; only loaded while scoring
+	// recommendations and for profile compatibility
+	Answers map[int]QuestionAnswer `json:"-"`
+}
+
+// a multiple-choice question of the compatibility questionnaire
+type Question struct {
+	ID      int      `json:"id"`
+	Text    string   `json:"text"`
+	Options []string `json:"options"`
+	Active  bool     `json:"active"`
+}
+
+// a user's own answer to a question, the answers they accept from a partner
+// and how much it matters to them
+type QuestionAnswer struct {
+	Answer     string   `json:"answer"`
+	Acceptable []string `json:"acceptable"`
+	Importance string   `json:"importance"`
+}
+
+// an optional profile attribute registered in attribute_definitions
+type AttributeDefinition struct {
+	ID            int      `json:"id"`
+	Name          string   `json:"name"`
+	Label         string   `json:"label"`
+	Type          string   `json:"type"` // "int", "enum", "multi_enum" or "bool"
+	AllowedValues []string `json:"allowed_values,omitempty"`
+	MinValue      *int     `json:"min_value,omitempty"`
+	MaxValue      *int     `json:"max_value,omitempty"`
+	Filterable    bool     `json:"filterable"`
+	Dealbreaker   bool     `json:"dealbreaker"` // whether a filter on it may exclude candidates
+}
+
+// a user's requirement on a partner's attribute
+// enum-like attributes use Values, "int" attributes use Min/Max
+type AttributeFilter struct {
+	Values      []string `json:"values,omitempty"`
+	Min         *int     `json:"min,omitempty"`
+	Max         *int     `json:"max,omitempty"`
+	Dealbreaker bool     `json:"dealbreaker"`
 }
 
 type Chat struct {
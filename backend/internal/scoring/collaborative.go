@@ -0,0 +1,81 @@
+package scoring
+
+import (
+	"math"
+	"sort"
+)
+
+// how a user reacted to another user
+const (
+	ReactionLike    = 1.0  // sent a connection request
+	ReactionDismiss = -1.0 // dismissed the recommendation
+)
+
+// a user with similar taste and how similar it is, in (0, 1]
+type Neighbor struct {
+	UserID     int
+	Similarity float64
+}
+
+// finds, for every user, the topK users whose likes and dismissals agree most
+// with theirs; reactions maps user -> target -> ReactionLike or ReactionDismiss
+// similarity is the cosine of the two reaction vectors, scaled down when fewer
+// than minOverlap targets were reacted to by both; only positive similarities are kept
+func UserSimilarities(reactions map[int]map[int]float64, minOverlap, topK int) map[int][]Neighbor {
+	type reaction struct {
+		userID int
+		value  float64
+	}
+	byTarget := make(map[int][]reaction)
+	norms := make(map[int]float64, len(reactions))
+	for userID, targets := range reactions {
+		var sum float64
+		for targetID, value := range targets {
+			byTarget[targetID] = append(byTarget[targetID], reaction{userID, value})
+			sum += value * value
+		}
+		norms[userID] = math.Sqrt(sum)
+	}
+
+	result := make(map[int][]Neighbor, len(reactions))
+	for userID, targets := range reactions {
+		if norms[userID] == 0 {
+			continue
+		}
+		dot := make(map[int]float64)
+		overlap := make(map[int]int)
+		for targetID, value := range targets {
+			for _, other := range byTarget[targetID] {
+				if other.userID == userID {
+					continue
+				}
+				dot[other.userID] += value * other.value
+				overlap[other.userID]++
+			}
+		}
+
+		var neighbors []Neighbor
+		for otherID, d := range dot {
+			sim := d / (norms[userID] * norms[otherID])
+			if n := overlap[otherID]; n < minOverlap {
+				sim *= float64(n) / float64(minOverlap)
+			}
+			if sim > 0 {
+				neighbors = append(neighbors, Neighbor{UserID: otherID, Similarity: sim})
+			}
+		}
+		sort.Slice(neighbors, func(i, j int) bool {
+			if neighbors[i].Similarity != neighbors[j].Similarity {
+				return neighbors[i].Similarity > neighbors[j].Similarity
+			}
+			return neighbors[i].UserID < neighbors[j].UserID
+		})
+		if topK > 0 && len(neighbors) > topK {
+			neighbors = neighbors[:topK]
+		}
+		if len(neighbors) > 0 {
+			result[userID] = neighbors
+		}
+	}
+	return result
+}
@@ -0,0 +1,81 @@
+package scoring
+
+import (
+	"math"
+
+	"matchme-backend/internal/models"
+)
+
+// how much a questionnaire answer matters to the person who gave it
+const (
+	ImportanceIrrelevant = "irrelevant"
+	ImportanceLittle     = "a_little"
+	ImportanceSomewhat   = "somewhat"
+	ImportanceVery       = "very"
+	ImportanceMandatory  = "mandatory"
+)
+
+// ImportanceLevels in increasing order
+var ImportanceLevels = []string{ImportanceIrrelevant, ImportanceLittle, ImportanceSomewhat, ImportanceVery, ImportanceMandatory}
+
+// points at stake for each importance level, as on OkCupid
+var importanceWeights = map[string]float64{
+	ImportanceIrrelevant: 0,
+	ImportanceLittle:     1,
+	ImportanceSomewhat:   10,
+	ImportanceVery:       50,
+	ImportanceMandatory:  250,
+}
+
+// OkCupid-style match between two sets of answers, in [0, 1], and the number
+// of questions both answered
+// each side earns the importance points of the shared questions where the
+// other's answer is acceptable to them; the match is the geometric mean of
+// both sides' shares, lowered by a margin of error of 1/common so that a few
+// shared questions cannot produce a high match
+func Compatibility(a, b map[int]models.QuestionAnswer) (float64, int) {
+	var earnedA, totalA, earnedB, totalB float64
+	common := 0
+	for id, ansA := range a {
+		ansB, ok := b[id]
+		if !ok {
+			continue
+		}
+		common++
+		wa := importanceWeights[ansA.Importance]
+		totalA += wa
+		if containsFold(ansA.Acceptable, ansB.Answer) {
+			earnedA += wa
+		}
+		wb := importanceWeights[ansB.Importance]
+		totalB += wb
+		if containsFold(ansB.Acceptable, ansA.Answer) {
+			earnedB += wb
+		}
+	}
+	if common == 0 {
+		return 0, 0
+	}
+	match := math.Sqrt(satisfaction(earnedA, totalA) * satisfaction(earnedB, totalB))
+	return math.Max(0, match-1/float64(common)), common
+}
+
+// the share of points earned; someone who cares about none of the shared
+// questions is fully satisfied
+func satisfaction(earned, total float64) float64 {
+	if total == 0 {
+		return 1
+	}
+	return earned / total
+}
+
+// the questionnaire match of the viewer and the target; 0 when they have no
+// answered questions in common
+type CompatibilitySignal struct{}
+
+func (CompatibilitySignal) Name() string { return "compatibility" }
+
+func (CompatibilitySignal) Score(viewer, target models.User) (float64, bool) {
+	match, _ := Compatibility(viewer.Answers, target.Answers)
+	return match, false
+}
@@ -0,0 +1,234 @@
+package scoring
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"sort"
+)
+
+// weight and parameters for one signal in a strategy
+type SignalConfig struct {
+	Weight float64 `json:"weight"`
+	// hobbies, interests: how much a preferred shared item counts
+	PreferredMultiplier float64 `json:"preferred_multiplier,omitempty"`
+	// location: exclude candidates from other cities
+	RequireSameCity bool `json:"require_same_city,omitempty"`
+	// activity: days after which the activity value halves
+	HalfLifeDays float64 `json:"half_life_days,omitempty"`
+	// distance: distance at which the distance value reaches 0
+	MaxKm float64 `json:"max_km,omitempty"`
+	// newcomer: days after completing the profile during which users get a boost
+	WindowDays float64 `json:"window_days,omitempty"`
+}
+
+type StrategyConfig struct {
+	Threshold      float64 `json:"threshold"`
+	CandidateScope string  `json:"candidate_scope"`
+	Reciprocal     bool    `json:"reciprocal"`
+	// candidates inactive for more days are excluded; 0 keeps everyone
+	InactiveAfterDays float64                 `json:"inactive_after_days"`
+	Signals           map[string]SignalConfig `json:"signals"`
+}
+
+// all strategies known to a deployment and the one it uses
+type Config struct {
+	Active     string                    `json:"active"`
+	Strategies map[string]StrategyConfig `json:"strategies"`
+	// optional: users are split between the variants' strategies instead of using Active
+	Experiment *ExperimentConfig `json:"experiment,omitempty"`
+}
+
+// builds each signal from its configuration
+var signalFactories = map[string]func(SignalConfig) Signal{
+	"location":      func(c SignalConfig) Signal { return LocationSignal{RequireSameCity: c.RequireSameCity} },
+	"age":           func(SignalConfig) Signal { return AgeSignal{} },
+	"gender":        func(SignalConfig) Signal { return GenderSignal{} },
+	"hobbies":       func(c SignalConfig) Signal { return HobbiesSignal{PreferredMultiplier: c.PreferredMultiplier} },
+	"interests":     func(c SignalConfig) Signal { return InterestsSignal{PreferredMultiplier: c.PreferredMultiplier} },
+	"attributes":    func(SignalConfig) Signal { return AttributesSignal{} },
+	"activity":      func(c SignalConfig) Signal { return ActivitySignal{HalfLifeDays: c.HalfLifeDays} },
+	"distance":      func(c SignalConfig) Signal { return DistanceSignal{MaxKm: c.MaxKm} },
+	"collaborative": func(SignalConfig) Signal { return CollaborativeSignal{} },
+	"bio":           func(SignalConfig) Signal { return BioSignal{} },
+	"newcomer":      func(c SignalConfig) Signal { return NewcomerSignal{WindowDays: c.WindowDays} },
+	"compatibility": func(SignalConfig) Signal { return CompatibilitySignal{} },
+}
+
+// built-in strategies stop recommending people who have not been around for this long
+const defaultInactiveAfterDays = 90
+
+// the built-in strategies
+// "classic_one_sided" is the original computeMatchScore: +1 country, +2 city,
+// +2 age, +2 gender, shared hobbies and interests with x2 for preferred items,
+// threshold 8, without checking the target's preferences or activity;
+// "classic" checks both sides and adds matching attributes, up to +1 each for
+// recent activity and a newly completed profile and up to +2 for
+// questionnaire compatibility, and drops dormant users
+func DefaultConfig() Config {
+	return Config{
+		Active: "classic",
+		Strategies: map[string]StrategyConfig{
+			"classic": {
+				Threshold:         8,
+				CandidateScope:    ScopeCity,
+				Reciprocal:        true,
+				InactiveAfterDays: defaultInactiveAfterDays,
+				Signals:           classicSignals(),
+			},
+			"classic_one_sided": {
+				Threshold:      8,
+				CandidateScope: ScopeCity,
+				Signals:        originalSignals(),
+			},
+			"balanced": {
+				Threshold:         6,
+				CandidateScope:    ScopeCountry,
+				Reciprocal:        true,
+				InactiveAfterDays: defaultInactiveAfterDays,
+				Signals: map[string]SignalConfig{
+					"location":      {Weight: 2},
+					"distance":      {Weight: 2, MaxKm: 500},
+					"age":           {Weight: 2},
+					"gender":        {Weight: 2},
+					"hobbies":       {Weight: 1, PreferredMultiplier: 2},
+					"interests":     {Weight: 1, PreferredMultiplier: 2},
+					"attributes":    {Weight: 1},
+					"activity":      {Weight: 2, HalfLifeDays: 14},
+					"collaborative": {Weight: 2},
+					"bio":           {Weight: 2},
+					"newcomer":      {Weight: 1, WindowDays: 7},
+					"compatibility": {Weight: 3},
+				},
+			},
+		},
+	}
+}
+
+// the fixed weights of the original computeMatchScore
+func originalSignals() map[string]SignalConfig {
+	return map[string]SignalConfig{
+		"location":  {Weight: 3, RequireSameCity: true},
+		"age":       {Weight: 2},
+		"gender":    {Weight: 2},
+		"hobbies":   {Weight: 1, PreferredMultiplier: 2},
+		"interests": {Weight: 1, PreferredMultiplier: 2},
+	}
+}
+
+func classicSignals() map[string]SignalConfig {
+	signals := originalSignals()
+	signals["attributes"] = SignalConfig{Weight: 1}
+	signals["activity"] = SignalConfig{Weight: 1, HalfLifeDays: 14}
+	signals["newcomer"] = SignalConfig{Weight: 1, WindowDays: 7}
+	signals["compatibility"] = SignalConfig{Weight: 2}
+	return signals
+}
+
+// reads a JSON configuration file
+func LoadConfig(path string) (Config, error) {
+	var cfg Config
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return cfg, err
+	}
+	if err := json.Unmarshal(data, &cfg); err != nil {
+		return cfg, fmt.Errorf("parse %s: %w", path, err)
+	}
+	return cfg, nil
+}
+
+// builds a strategy from its configuration
+func BuildStrategy(name string, sc StrategyConfig) (*Strategy, error) {
+	scope := sc.CandidateScope
+	if scope == "" {
+		scope = ScopeCity
+	}
+	if scope != ScopeCity && scope != ScopeCountry {
+		return nil, fmt.Errorf("strategy %s: unknown candidate scope %q", name, scope)
+	}
+
+	// build signals in a fixed order so scores are reproducible
+	names := make([]string, 0, len(sc.Signals))
+	for n := range sc.Signals {
+		names = append(names, n)
+	}
+	sort.Strings(names)
+
+	if sc.InactiveAfterDays < 0 {
+		return nil, fmt.Errorf("strategy %s: inactive_after_days must not be negative", name)
+	}
+
+	s := &Strategy{
+		Name:              name,
+		Threshold:         sc.Threshold,
+		CandidateScope:    scope,
+		Reciprocal:        sc.Reciprocal,
+		InactiveAfterDays: sc.InactiveAfterDays,
+	}
+	for _, n := range names {
+		factory, ok := signalFactories[n]
+		if !ok {
+			return nil, fmt.Errorf("strategy %s: unknown signal %q", name, n)
+		}
+		c := sc.Signals[n]
+		s.Signals = append(s.Signals, WeightedSignal{Signal: factory(c), Weight: c.Weight})
+	}
+	return s, nil
+}
+
+var (
+	strategies = map[string]*Strategy{}
+	// the strategy used for recommendations in this deployment
+	Current *Strategy
+)
+
+// returns a configured strategy by name
+func Get(name string) (*Strategy, bool) {
+	s, ok := strategies[name]
+	return s, ok
+}
+
+// loads the scoring configuration, selects the active strategy and starts the
+// experiment if one is configured
+// MATCHME_SCORING_CONFIG points to a JSON config file (built-in strategies otherwise),
+// MATCHME_SCORING_STRATEGY overrides the active strategy
+func InitScoring() {
+	cfg := DefaultConfig()
+	if path := os.Getenv("MATCHME_SCORING_CONFIG"); path != "" {
+		loaded, err := LoadConfig(path)
+		if err != nil {
+			log.Fatalf("Failed to load scoring config: %v\n", err)
+		}
+		cfg = loaded
+	}
+	if name := os.Getenv("MATCHME_SCORING_STRATEGY"); name != "" {
+		cfg.Active = name
+	}
+
+	built := make(map[string]*Strategy)
+	for name, sc := range cfg.Strategies {
+		s, err := BuildStrategy(name, sc)
+		if err != nil {
+			log.Fatalf("Invalid scoring config: %v\n", err)
+		}
+		built[name] = s
+	}
+	active, ok := built[cfg.Active]
+	if !ok {
+		log.Fatalf("Scoring strategy %q is not configured\n", cfg.Active)
+	}
+
+	if cfg.Experiment != nil {
+		if err := validateExperiment(cfg.Experiment, built); err != nil {
+			log.Fatalf("Invalid scoring config: %v\n", err)
+		}
+		log.Printf("Running experiment: %s (%d variants)\n", cfg.Experiment.Name, len(cfg.Experiment.Variants))
+	}
+
+	strategies = built
+	Current = active
+	currentExperiment = cfg.Experiment
+	log.Printf("Using scoring strategy: %s\n", active.Name)
+}
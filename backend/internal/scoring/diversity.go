@@ -0,0 +1,115 @@
+package scoring
+
+import (
+	"math"
+	"strings"
+
+	"matchme-backend/internal/models"
+	"matchme-backend/internal/utils"
+)
+
+// age difference at which two candidates no longer count as alike in age
+const similarAgeYears = 10.0
+
+// a scored candidate considered for re-ranking
+type Ranked struct {
+	User  models.User
+	Score float64
+}
+
+// how alike two candidates are, in [0, 1]: the overlap of their hobbies and
+// interests (Jaccard) weighted 2:1 against their closeness in age
+func ProfileSimilarity(a, b models.User) float64 {
+	items := jaccard(profileItems(a), profileItems(b))
+
+	age := 0.0
+	if a.Birthdate != nil && b.Birthdate != nil {
+		diff := math.Abs(float64(utils.CalcAge(*a.Birthdate) - utils.CalcAge(*b.Birthdate)))
+		age = math.Max(0, 1-diff/similarAgeYears)
+	}
+	return (2*items + age) / 3
+}
+
+// picks up to k candidates by maximal marginal relevance: each pick maximises
+// lambda*relevance - (1-lambda)*(highest similarity to an earlier pick), where
+// relevance is the score scaled to [0, 1] within items; lambda 1 keeps score order
+// items must be sorted best first, which also settles ties
+func DiversityRerank(items []Ranked, k int, lambda float64) []Ranked {
+	if k > len(items) {
+		k = len(items)
+	}
+	if k <= 0 {
+		return nil
+	}
+
+	minScore, maxScore := items[0].Score, items[0].Score
+	for _, it := range items {
+		minScore = math.Min(minScore, it.Score)
+		maxScore = math.Max(maxScore, it.Score)
+	}
+	relevance := func(score float64) float64 {
+		if maxScore == minScore {
+			return 1
+		}
+		return (score - minScore) / (maxScore - minScore)
+	}
+
+	picked := make([]Ranked, 0, k)
+	used := make([]bool, len(items))
+	// highest similarity of each remaining item to anything picked so far
+	maxSim := make([]float64, len(items))
+	for len(picked) < k {
+		best, bestValue := -1, math.Inf(-1)
+		for i, it := range items {
+			if used[i] {
+				continue
+			}
+			value := lambda*relevance(it.Score) - (1-lambda)*maxSim[i]
+			if value > bestValue {
+				best, bestValue = i, value
+			}
+		}
+		used[best] = true
+		picked = append(picked, items[best])
+		for i, it := range items {
+			if !used[i] {
+				maxSim[i] = math.Max(maxSim[i], ProfileSimilarity(items[best].User, it.User))
+			}
+		}
+	}
+	return picked
+}
+
+// hobbies and interests together
+func profileItems(u models.User) []string {
+	var items []string
+	if u.Hobbies != nil {
+		items = append(items, *u.Hobbies...)
+	}
+	if u.Interests != nil {
+		items = append(items, *u.Interests...)
+	}
+	return items
+}
+
+// size of the intersection over the size of the union, ignoring case
+func jaccard(a, b []string) float64 {
+	setA := make(map[string]bool, len(a))
+	for _, item := range a {
+		setA[strings.ToLower(item)] = true
+	}
+	setB := make(map[string]bool, len(b))
+	for _, item := range b {
+		setB[strings.ToLower(item)] = true
+	}
+	if len(setA) == 0 && len(setB) == 0 {
+		return 0
+	}
+	shared := 0
+	for item := range setB {
+		if setA[item] {
+			shared++
+		}
+	}
+	return float64(shared) / float64(len(setA)+len(setB)-shared)
+}
@@ -0,0 +1,95 @@
+package scoring
+
+import (
+	"fmt"
+	"hash/fnv"
+	"strconv"
+)
+
+// one arm of an experiment: the strategy its users get and their share of traffic
+type VariantConfig struct {
+	Name     string `json:"name"`
+	Strategy string `json:"strategy"`
+	Weight   int    `json:"weight"`
+}
+
+// splits users between strategies to compare them
+type ExperimentConfig struct {
+	Name     string          `json:"name"`
+	Variants []VariantConfig `json:"variants"`
+}
+
+// the experiment running in this deployment, nil when none is
+var currentExperiment *ExperimentConfig
+
+// checks that every variant has a name, a positive weight and a configured strategy
+func validateExperiment(e *ExperimentConfig, built map[string]*Strategy) error {
+	if e.Name == "" {
+		return fmt.Errorf("experiment has no name")
+	}
+	if len(e.Variants) == 0 {
+		return fmt.Errorf("experiment %s has no variants", e.Name)
+	}
+	seen := make(map[string]bool)
+	for _, v := range e.Variants {
+		if v.Name == "" || seen[v.Name] {
+			return fmt.Errorf("experiment %s: variant names must be unique and non-empty", e.Name)
+		}
+		seen[v.Name] = true
+		if v.Weight <= 0 {
+			return fmt.Errorf("experiment %s: variant %s needs a positive weight", e.Name, v.Name)
+		}
+		if _, ok := built[v.Strategy]; !ok {
+			return fmt.Errorf("experiment %s: variant %s uses unknown strategy %q", e.Name, v.Name, v.Strategy)
+		}
+	}
+	return nil
+}
+
+// deterministically picks a variant for the user: the same user always lands
+// in the same variant of an experiment, independently across experiments
+func (e *ExperimentConfig) Bucket(userID int) VariantConfig {
+	total := 0
+	for _, v := range e.Variants {
+		total += v.Weight
+	}
+	h := fnv.New32a()
+	h.Write([]byte(e.Name + ":" + strconv.Itoa(userID)))
+	point := int(h.Sum32() % uint32(total))
+	for _, v := range e.Variants {
+		if point < v.Weight {
+			return v
+		}
+		point -= v.Weight
+	}
+	return e.Variants[len(e.Variants)-1]
+}
+
+// the running experiment, or nil
+func CurrentExperiment() *ExperimentConfig {
+	return currentExperiment
+}
+
+// the strategy used for the user's recommendations, with the experiment and
+// variant that chose it (both empty outside an experiment)
+func StrategyFor(userID int) (strategy *Strategy, experiment, variant string) {
+	if currentExperiment == nil {
+		return Current, "", ""
+	}
+	v := currentExperiment.Bucket(userID)
+	return strategies[v.Strategy], currentExperiment.Name, v.Name
+}
+
+// whether the named signal is used for recommendations: by the active
+// strategy or by a variant of the running experiment
+func InUse(signal string) bool {
+	if currentExperiment == nil {
+		return Current.Uses(signal)
+	}
+	for _, v := range currentExperiment.Variants {
+		if strategies[v.Strategy].Uses(signal) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,88 @@
+package scoring
+
+import (
+	"strings"
+
+	"matchme-backend/internal/models"
+)
+
+// one signal's share of a score
+type Contribution struct {
+	Signal string  `json:"signal"`
+	Value  float64 `json:"value"`
+	Weight float64 `json:"weight"`
+	Points float64 `json:"points"`
+	// the signal rejects the target outright
+	Excluded bool `json:"excluded,omitempty"`
+}
+
+// how a strategy arrived at a score
+type Explanation struct {
+	Strategy string  `json:"strategy"`
+	Score    float64 `json:"score"`
+	Skipped  bool    `json:"skipped"`
+	// the viewer's view of the target
+	Contributions []Contribution `json:"contributions"`
+	// the target's view of the viewer, for reciprocal strategies
+	ReverseContributions []Contribution `json:"reverse_contributions,omitempty"`
+}
+
+// a shared hobby or interest and whether the viewer marked it as preferred
+type SharedItem struct {
+	Name      string `json:"name"`
+	Preferred bool   `json:"preferred"`
+}
+
+// scores like Score but records every signal's contribution
+func (s *Strategy) Explain(viewer, target models.User) Explanation {
+	e := Explanation{Strategy: s.Name}
+
+	score, skip := s.explainDirection(viewer, target, &e.Contributions)
+	if s.Reciprocal {
+		back, backSkip := s.explainDirection(target, viewer, &e.ReverseContributions)
+		skip = skip || backSkip
+		score = HarmonicMean(score, back)
+	}
+	if skip || s.IsDormant(target) {
+		skip = true
+		score = 0
+	}
+	e.Score = score
+	e.Skipped = skip || score < s.Threshold
+	return e
+}
+
+func (s *Strategy) explainDirection(viewer, target models.User, out *[]Contribution) (float64, bool) {
+	var score float64
+	skipped := false
+	for _, ws := range s.Signals {
+		value, skip := ws.Signal.Score(viewer, target)
+		c := Contribution{Signal: ws.Signal.Name(), Value: value, Weight: ws.Weight, Excluded: skip}
+		if !skip {
+			c.Points = ws.Weight * value
+			score += c.Points
+		}
+		skipped = skipped || skip
+		*out = append(*out, c)
+	}
+	return score, skipped
+}
+
+// lists the items both users have, in the target's order
+func SharedItems(viewerItems, targetItems, preferred *[]string) []SharedItem {
+	shared := []SharedItem{}
+	if viewerItems == nil || targetItems == nil {
+		return shared
+	}
+	seen := make(map[string]bool)
+	for _, item := range *targetItems {
+		key := strings.ToLower(item)
+		if seen[key] || !containsFold(*viewerItems, item) {
+			continue
+		}
+		seen[key] = true
+		isPreferred := preferred != nil && containsFold(*preferred, item)
+		shared = append(shared, SharedItem{Name: item, Preferred: isPreferred})
+	}
+	return shared
+}
@@ -0,0 +1,50 @@
+package scoring
+
+import (
+	"math"
+	"strings"
+)
+
+const earthRadiusKm = 6371.0
+
+// approximate coordinates (lat, lon) of the cities users can pick
+var cityCoordinates = map[string][2]float64{
+	"new york":    {40.7128, -74.0060},
+	"los angeles": {34.0522, -118.2437},
+	"chicago":     {41.8781, -87.6298},
+	"houston":     {29.7604, -95.3698},
+	"dallas":      {32.7767, -96.7970},
+	"toronto":     {43.6532, -79.3832},
+	"vancouver":   {49.2827, -123.1207},
+	"montreal":    {45.5017, -73.5673},
+	"calgary":     {51.0447, -114.0719},
+	"london":      {51.5074, -0.1278},
+	"manchester":  {53.4808, -2.2426},
+	"liverpool":   {53.4084, -2.9916},
+	"birmingham":  {52.4862, -1.8904},
+	"mexico city": {19.4326, -99.1332},
+	"guadalajara": {20.6597, -103.3496},
+	"monterrey":   {25.6866, -100.3161},
+	"berlin":      {52.5200, 13.4050},
+	"hamburg":     {53.5511, 9.9937},
+	"munich":      {48.1351, 11.5820},
+	"frankfurt":   {50.1109, 8.6821},
+	"tallinn":     {59.4370, 24.7536},
+	"tartu":       {58.3780, 26.7290},
+	"narva":       {59.3797, 28.1791},
+	"pärnu":       {58.3859, 24.4971},
+}
+
+// great-circle distance between two known cities; ok is false if either is unknown
+func cityDistanceKm(a, b string) (float64, bool) {
+	ca, okA := cityCoordinates[strings.ToLower(a)]
+	cb, okB := cityCoordinates[strings.ToLower(b)]
+	if !okA || !okB {
+		return 0, false
+	}
+	lat1, lon1 := ca[0]*math.Pi/180, ca[1]*math.Pi/180
+	lat2, lon2 := cb[0]*math.Pi/180, cb[1]*math.Pi/180
+	h := math.Pow(math.Sin((lat2-lat1)/2), 2) +
+		math.Cos(lat1)*math.Cos(lat2)*math.Pow(math.Sin((lon2-lon1)/2), 2)
+	return 2 * earthRadiusKm * math.Asin(math.Sqrt(h)), true
+}
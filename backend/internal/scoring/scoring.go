@@ -0,0 +1,111 @@
+package scoring
+
+import (
+	"time"
+
+	"matchme-backend/internal/models"
+)
+
+// one component of the match score
+// Score returns a value (usually in [0, 1] or a count of shared items) and
+// whether the target must be excluded regardless of the other signals
+type Signal interface {
+	Name() string
+	Score(viewer, target models.User) (value float64, skip bool)
+}
+
+// a signal together with its weight in a strategy
+type WeightedSignal struct {
+	Signal Signal
+	Weight float64
+}
+
+// scores a target for a viewer; skip is true when the target must not be recommended
+type Scorer interface {
+	Score(viewer, target models.User) (score float64, skip bool)
+}
+
+// which candidates are loaded before scoring
+const (
+	ScopeCity    = "city"
+	ScopeCountry = "country"
+)
+
+// a named scorer: the weighted sum of its signals, cut off at a threshold
+type Strategy struct {
+	Name      string
+	Signals   []WeightedSignal
+	Threshold float64
+	// ScopeCity or ScopeCountry, used by the candidate query
+	CandidateScope string
+	// score both directions and combine them with the harmonic mean,
+	// instead of only checking the target against the viewer's preferences
+	Reciprocal bool
+	// targets inactive for longer than this are never recommended; 0 keeps everyone
+	InactiveAfterDays float64
+}
+
+// scores the target for the viewer (and the viewer for the target when reciprocal)
+// any signal asking to skip excludes the target, as does a total below the
+// threshold or a target that is dormant
+func (s *Strategy) Score(viewer, target models.User) (float64, bool) {
+	if s.IsDormant(target) {
+		return 0, true
+	}
+	score, skip := s.directionalScore(viewer, target)
+	if skip {
+		return 0, true
+	}
+	if s.Reciprocal {
+		back, skip := s.directionalScore(target, viewer)
+		if skip {
+			return 0, true
+		}
+		score = HarmonicMean(score, back)
+	}
+	if score < s.Threshold {
+		return score, true
+	}
+	return score, false
+}
+
+// reports whether the user has been inactive for longer than InactiveAfterDays
+// users whose activity is unknown are not dormant
+func (s *Strategy) IsDormant(u models.User) bool {
+	if s.InactiveAfterDays <= 0 || u.LastActiveAt == nil {
+		return false
+	}
+	return time.Since(*u.LastActiveAt).Hours()/24 > s.InactiveAfterDays
+}
+
+// the weighted sum of all signals from the viewer's point of view
+func (s *Strategy) directionalScore(viewer, target models.User) (float64, bool) {
+	var score float64
+	for _, ws := range s.Signals {
+		value, skip := ws.Signal.Score(viewer, target)
+		if skip {
+			return 0, true
+		}
+		score += ws.Weight * value
+	}
+	return score, false
+}
+
+// combines two directional scores so that a match must suit both sides;
+// it stays close to the lower of the two and equals them when they agree
+func HarmonicMean(a, b float64) float64 {
+	if a <= 0 || b <= 0 {
+		return 0
+	}
+	return 2 * a * b / (a + b)
+}
+
+// reports whether the strategy has a signal with this name
+func (s *Strategy) Uses(name string) bool {
+	for _, ws := range s.Signals {
+		if ws.Signal.Name() == name {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,334 @@
+package scoring
+
+import (
+	"fmt"
+	"math"
+	"strings"
+	"time"
+
+	"matchme-backend/internal/models"
+	"matchme-backend/internal/text"
+	"matchme-backend/internal/utils"
+)
+
+// requires the same country (and the same city when RequireSameCity is set)
+// 1 for the same city, 0 for another city in the same country
+type LocationSignal struct {
+	RequireSameCity bool
+}
+
+func (LocationSignal) Name() string { return "location" }
+
+func (s LocationSignal) Score(viewer, target models.User) (float64, bool) {
+	if viewer.Country == nil || target.Country == nil || !strings.EqualFold(*viewer.Country, *target.Country) {
+		return 0, true
+	}
+	sameCity := viewer.City != nil && target.City != nil && strings.EqualFold(*viewer.City, *target.City)
+	if sameCity {
+		return 1, false
+	}
+	if s.RequireSameCity {
+		return 0, true
+	}
+	return 0, false
+}
+
+// how strictly a preference applies
+const (
+	StrictnessHard = "hard"
+	StrictnessSoft = "soft"
+)
+
+// unset strictness means hard
+func isSoft(strictness *string) bool {
+	return strictness != nil && strings.EqualFold(*strictness, StrictnessSoft)
+}
+
+func intOrZero(i *int) int {
+	if i == nil {
+		return 0
+	}
+	return *i
+}
+
+// checks the target's age against the viewer's min–max range, widened by the
+// viewer's tolerance: 1 inside the range, dropping by 1/(tolerance+1) per year
+// outside it; a hard preference excludes targets beyond the tolerance, a soft
+// one lets the value fall to -1 instead
+type AgeSignal struct{}
+
+func (AgeSignal) Name() string { return "age" }
+
+func (AgeSignal) Score(viewer, target models.User) (float64, bool) {
+	if viewer.LookingForMinAge == nil || viewer.LookingForMaxAge == nil || target.Birthdate == nil {
+		return 0, true
+	}
+	age := utils.CalcAge(*target.Birthdate)
+	outside := 0
+	if age < *viewer.LookingForMinAge {
+		outside = *viewer.LookingForMinAge - age
+	} else if age > *viewer.LookingForMaxAge {
+		outside = age - *viewer.LookingForMaxAge
+	}
+	tolerance := intOrZero(viewer.AgeTolerance)
+	if outside > tolerance && !isSoft(viewer.AgeStrictness) {
+		return 0, true
+	}
+	return math.Max(-1, 1-float64(outside)/float64(tolerance+1)), false
+}
+
+// checks the target's gender when the viewer is looking for a specific one
+// 1 for a required match, 0 when the viewer accepts any gender; a mismatch
+// excludes the target, or counts -1 when the preference is soft
+type GenderSignal struct{}
+
+func (GenderSignal) Name() string { return "gender" }
+
+func (GenderSignal) Score(viewer, target models.User) (float64, bool) {
+	if viewer.LookingForGender == nil || strings.EqualFold(*viewer.LookingForGender, "any") {
+		return 0, false
+	}
+	if target.Gender == nil || !strings.EqualFold(*viewer.LookingForGender, *target.Gender) {
+		if isSoft(viewer.GenderStrictness) {
+			return -1, false
+		}
+		return 0, true
+	}
+	return 1, false
+}
+
+// counts hobbies both users share, items the viewer prefers count PreferredMultiplier times
+// below the viewer's minimum the target is excluded, or loses 1 per missing item when soft
+type HobbiesSignal struct {
+	PreferredMultiplier float64
+}
+
+func (HobbiesSignal) Name() string { return "hobbies" }
+
+func (s HobbiesSignal) Score(viewer, target models.User) (float64, bool) {
+	return applySharedMinimum(
+		sharedItemsScore(viewer.Hobbies, target.Hobbies, viewer.PreferredHobbies, s.PreferredMultiplier),
+		sharedCount(viewer.Hobbies, target.Hobbies), viewer.MinSharedHobbies, viewer.SharedStrictness)
+}
+
+// counts interests both users share, items the viewer prefers count PreferredMultiplier times
+// below the viewer's minimum the target is excluded, or loses 1 per missing item when soft
+type InterestsSignal struct {
+	PreferredMultiplier float64
+}
+
+func (InterestsSignal) Name() string { return "interests" }
+
+func (s InterestsSignal) Score(viewer, target models.User) (float64, bool) {
+	return applySharedMinimum(
+		sharedItemsScore(viewer.Interests, target.Interests, viewer.PreferredInterests, s.PreferredMultiplier),
+		sharedCount(viewer.Interests, target.Interests), viewer.MinSharedInterests, viewer.SharedStrictness)
+}
+
+func applySharedMinimum(value float64, shared int, minimum *int, strictness *string) (float64, bool) {
+	missing := intOrZero(minimum) - shared
+	if missing <= 0 {
+		return value, false
+	}
+	if !isSoft(strictness) {
+		return 0, true
+	}
+	return value - float64(missing), false
+}
+
+// how many distinct items both lists contain, ignoring case
+func sharedCount(a, b *[]string) int {
+	return len(SharedItems(a, b, nil))
+}
+
+func sharedItemsScore(viewerItems, targetItems, preferred *[]string, multiplier float64) float64 {
+	if viewerItems == nil || targetItems == nil {
+		return 0
+	}
+	own := make(map[string]bool)
+	for _, item := range *viewerItems {
+		own[strings.ToLower(item)] = true
+	}
+	pref := make(map[string]bool)
+	if preferred != nil {
+		for _, item := range *preferred {
+			pref[strings.ToLower(item)] = true
+		}
+	}
+
+	var score float64
+	seen := make(map[string]bool)
+	for _, item := range *targetItems {
+		key := strings.ToLower(item)
+		if !own[key] || seen[key] {
+			continue
+		}
+		seen[key] = true
+		if pref[key] {
+			score += multiplier
+		} else {
+			score++
+		}
+	}
+	return score
+}
+
+// applies the viewer's attribute filters: each satisfied filter counts 1,
+// a failed dealbreaker excludes the target
+type AttributesSignal struct{}
+
+func (AttributesSignal) Name() string { return "attributes" }
+
+func (AttributesSignal) Score(viewer, target models.User) (float64, bool) {
+	var score float64
+	for name, filter := range viewer.AttributeFilters {
+		if filter == nil {
+			continue
+		}
+		if AttributeFilterMatches(filter, target.Attributes[name]) {
+			score++
+		} else if filter.Dealbreaker {
+			return 0, true
+		}
+	}
+	return score, false
+}
+
+// reports whether a candidate's attribute value satisfies a filter
+// a missing value never satisfies a filter
+func AttributeFilterMatches(f *models.AttributeFilter, value interface{}) bool {
+	switch v := value.(type) {
+	case int:
+		return AttributeFilterMatches(f, float64(v))
+	case float64:
+		if f.Min != nil && v < float64(*f.Min) {
+			return false
+		}
+		if f.Max != nil && v > float64(*f.Max) {
+			return false
+		}
+		return true
+	case string:
+		return containsFold(f.Values, v)
+	case bool:
+		return containsFold(f.Values, fmt.Sprint(v))
+	case []interface{}:
+		for _, item := range v {
+			if s, ok := item.(string); ok && containsFold(f.Values, s) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// 1 for someone active right now, halving every HalfLifeDays; 0 when unknown
+type ActivitySignal struct {
+	HalfLifeDays float64
+	now          func() time.Time
+}
+
+func (ActivitySignal) Name() string { return "activity" }
+
+func (s ActivitySignal) Score(_, target models.User) (float64, bool) {
+	if target.LastActiveAt == nil || s.HalfLifeDays <= 0 {
+		return 0, false
+	}
+	now := time.Now
+	if s.now != nil {
+		now = s.now
+	}
+	days := now().Sub(*target.LastActiveAt).Hours() / 24
+	if days < 0 {
+		days = 0
+	}
+	return math.Pow(0.5, days/s.HalfLifeDays), false
+}
+
+// 1 for the same city, falling linearly to 0 at MaxKm; 0 when a city is unknown
+type DistanceSignal struct {
+	MaxKm float64
+}
+
+func (DistanceSignal) Name() string { return "distance" }
+
+func (s DistanceSignal) Score(viewer, target models.User) (float64, bool) {
+	if viewer.City == nil || target.City == nil || s.MaxKm <= 0 {
+		return 0, false
+	}
+	km, ok := cityDistanceKm(*viewer.City, *target.City)
+	if !ok {
+		return 0, false
+	}
+	return math.Max(0, 1-km/s.MaxKm), false
+}
+
+func containsFold(list []string, value string) bool {
+	for _, item := range list {
+		if strings.EqualFold(item, value) {
+			return true
+		}
+	}
+	return false
+}
+
+// the classic age, hobby and interest points that give a full cold-start
+// value; the classic threshold
+const coldStartFullScore = 8
+
+// how much similar users liked (1) or dismissed (0) the target, from the
+// viewer's affinities; without that evidence it falls back to the classic
+// age, hobby and interest score, scaled so coldStartFullScore points give 1
+type CollaborativeSignal struct{}
+
+func (CollaborativeSignal) Name() string { return "collaborative" }
+
+func (CollaborativeSignal) Score(viewer, target models.User) (float64, bool) {
+	if affinity, ok := viewer.Affinities[target.UserID]; ok {
+		return (affinity + 1) / 2, false
+	}
+
+	// dealbreakers are left to the signals themselves
+	classic := originalSignals()
+	var points float64
+	for _, s := range []Signal{
+		AgeSignal{},
+		HobbiesSignal{PreferredMultiplier: classic["hobbies"].PreferredMultiplier},
+		InterestsSignal{PreferredMultiplier: classic["interests"].PreferredMultiplier},
+	} {
+		if value, excluded := s.Score(viewer, target); !excluded {
+			points += classic[s.Name()].Weight * value
+		}
+	}
+	return math.Max(0, math.Min(1, points/coldStartFullScore)), false
+}
+
+// cosine similarity of the TF-IDF vectors of the two bios; 0 when either is empty
+type BioSignal struct{}
+
+func (BioSignal) Name() string { return "bio" }
+
+func (BioSignal) Score(viewer, target models.User) (float64, bool) {
+	return text.Cosine(viewer.BioVector, target.BioVector), false
+}
+
+// a "new here" boost: 1 right after the target completed their profile,
+// fading linearly to 0 after WindowDays; 0 when unknown
+type NewcomerSignal struct {
+	WindowDays float64
+	now        func() time.Time
+}
+
+func (NewcomerSignal) Name() string { return "newcomer" }
+
+func (s NewcomerSignal) Score(_, target models.User) (float64, bool) {
+	if target.ProfileCompletedAt == nil || s.WindowDays <= 0 {
+		return 0, false
+	}
+	now := time.Now
+	if s.now != nil {
+		now = s.now
+	}
+	days := now().Sub(*target.ProfileCompletedAt).Hours() / 24
+	return math.Max(0, math.Min(1, 1-days/s.WindowDays)), false
+}
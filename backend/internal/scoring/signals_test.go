@@ -0,0 +1,534 @@
+package scoring
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"matchme-backend/internal/models"
+)
+
+func strPtr(s string) *string           { return &s }
+func intPtr(i int) *int                 { return &i }
+func listPtr(items ...string) *[]string { return &items }
+
+func birthdateForAge(age int) *string {
+	d := time.Now().AddDate(-age, 0, -1).Format("2006-01-02")
+	return &d
+}
+
+type signalCase struct {
+	name      string
+	viewer    models.User
+	target    models.User
+	wantValue float64
+	wantSkip  bool
+}
+
+func runSignalCases(t *testing.T, s Signal, cases []signalCase) {
+	t.Helper()
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			value, skip := s.Score(tc.viewer, tc.target)
+			if skip != tc.wantSkip {
+				t.Fatalf("skip = %v, want %v", skip, tc.wantSkip)
+			}
+			if math.Abs(value-tc.wantValue) > 1e-9 {
+				t.Fatalf("value = %v, want %v", value, tc.wantValue)
+			}
+		})
+	}
+}
+
+func TestLocationSignal(t *testing.T) {
+	tallinn := models.User{Country: strPtr("Estonia"), City: strPtr("Tallinn")}
+	tartu := models.User{Country: strPtr("Estonia"), City: strPtr("Tartu")}
+	berlin := models.User{Country: strPtr("Germany"), City: strPtr("Berlin")}
+
+	runSignalCases(t, LocationSignal{RequireSameCity: true}, []signalCase{
+		{name: "same city", viewer: tallinn, target: tallinn, wantValue: 1},
+		{name: "case insensitive", viewer: tallinn, target: models.User{Country: strPtr("estonia"), City: strPtr("TALLINN")}, wantValue: 1},
+		{name: "other city", viewer: tallinn, target: tartu, wantSkip: true},
+		{name: "other country", viewer: tallinn, target: berlin, wantSkip: true},
+		{name: "missing country", viewer: tallinn, target: models.User{}, wantSkip: true},
+	})
+	runSignalCases(t, LocationSignal{}, []signalCase{
+		{name: "same city", viewer: tallinn, target: tallinn, wantValue: 1},
+		{name: "other city allowed", viewer: tallinn, target: tartu, wantValue: 0},
+		{name: "other country", viewer: tallinn, target: berlin, wantSkip: true},
+	})
+}
+
+func TestAgeSignal(t *testing.T) {
+	viewer := models.User{LookingForMinAge: intPtr(25), LookingForMaxAge: intPtr(35)}
+
+	runSignalCases(t, AgeSignal{}, []signalCase{
+		{name: "inside range", viewer: viewer, target: models.User{Birthdate: birthdateForAge(30)}, wantValue: 1},
+		{name: "lower bound", viewer: viewer, target: models.User{Birthdate: birthdateForAge(25)}, wantValue: 1},
+		{name: "upper bound", viewer: viewer, target: models.User{Birthdate: birthdateForAge(35)}, wantValue: 1},
+		{name: "too young", viewer: viewer, target: models.User{Birthdate: birthdateForAge(24)}, wantSkip: true},
+		{name: "too old", viewer: viewer, target: models.User{Birthdate: birthdateForAge(36)}, wantSkip: true},
+		{name: "no birthdate", viewer: viewer, target: models.User{}, wantSkip: true},
+		{name: "no range", viewer: models.User{}, target: models.User{Birthdate: birthdateForAge(30)}, wantSkip: true},
+	})
+}
+
+func TestGenderSignal(t *testing.T) {
+	female := models.User{Gender: strPtr("female")}
+	male := models.User{Gender: strPtr("male")}
+
+	runSignalCases(t, GenderSignal{}, []signalCase{
+		{name: "wanted gender", viewer: models.User{LookingForGender: strPtr("female")}, target: female, wantValue: 1},
+		{name: "other gender", viewer: models.User{LookingForGender: strPtr("female")}, target: male, wantSkip: true},
+		{name: "any", viewer: models.User{LookingForGender: strPtr("any")}, target: male, wantValue: 0},
+		{name: "no preference", viewer: models.User{}, target: male, wantValue: 0},
+		{name: "target without gender", viewer: models.User{LookingForGender: strPtr("male")}, target: models.User{}, wantSkip: true},
+	})
+}
+
+func TestHobbiesSignal(t *testing.T) {
+	viewer := models.User{
+		Hobbies:          listPtr("Reading", "Gaming", "Cooking"),
+		PreferredHobbies: listPtr("Cooking"),
+	}
+
+	runSignalCases(t, HobbiesSignal{PreferredMultiplier: 2}, []signalCase{
+		{name: "no overlap", viewer: viewer, target: models.User{Hobbies: listPtr("Art")}, wantValue: 0},
+		{name: "one shared", viewer: viewer, target: models.User{Hobbies: listPtr("reading", "Art")}, wantValue: 1},
+		{name: "preferred counts double", viewer: viewer, target: models.User{Hobbies: listPtr("Reading", "Cooking")}, wantValue: 3},
+		{name: "duplicates count once", viewer: viewer, target: models.User{Hobbies: listPtr("Gaming", "gaming")}, wantValue: 1},
+		{name: "target without hobbies", viewer: viewer, target: models.User{}, wantValue: 0},
+	})
+	runSignalCases(t, HobbiesSignal{PreferredMultiplier: 5}, []signalCase{
+		{name: "configurable multiplier", viewer: viewer, target: models.User{Hobbies: listPtr("Cooking")}, wantValue: 5},
+	})
+}
+
+func TestInterestsSignal(t *testing.T) {
+	viewer := models.User{
+		Interests:          listPtr("Movies", "Music"),
+		PreferredInterests: listPtr("Music"),
+	}
+
+	runSignalCases(t, InterestsSignal{PreferredMultiplier: 2}, []signalCase{
+		{name: "no overlap", viewer: viewer, target: models.User{Interests: listPtr("Pets")}, wantValue: 0},
+		{name: "one shared", viewer: viewer, target: models.User{Interests: listPtr("Movies")}, wantValue: 1},
+		{name: "shared and preferred", viewer: viewer, target: models.User{Interests: listPtr("Movies", "Music")}, wantValue: 3},
+		{name: "viewer without interests", viewer: models.User{}, target: models.User{Interests: listPtr("Movies")}, wantValue: 0},
+	})
+}
+
+func TestPreferenceStrictness(t *testing.T) {
+	hard := models.User{LookingForMinAge: intPtr(25), LookingForMaxAge: intPtr(35), AgeTolerance: intPtr(2),
+		LookingForGender: strPtr("female")}
+	soft := hard
+	soft.AgeStrictness = strPtr(StrictnessSoft)
+	soft.GenderStrictness = strPtr(StrictnessSoft)
+
+	runSignalCases(t, AgeSignal{}, []signalCase{
+		{name: "hard within tolerance", viewer: hard, target: models.User{Birthdate: birthdateForAge(37)}, wantValue: 1.0 / 3},
+		{name: "hard beyond tolerance", viewer: hard, target: models.User{Birthdate: birthdateForAge(38)}, wantSkip: true},
+		{name: "soft beyond tolerance", viewer: soft, target: models.User{Birthdate: birthdateForAge(38)}, wantValue: 0},
+		{name: "soft far outside", viewer: soft, target: models.User{Birthdate: birthdateForAge(60)}, wantValue: -1},
+	})
+	runSignalCases(t, GenderSignal{}, []signalCase{
+		{name: "hard mismatch", viewer: hard, target: models.User{Gender: strPtr("male")}, wantSkip: true},
+		{name: "soft mismatch", viewer: soft, target: models.User{Gender: strPtr("male")}, wantValue: -1},
+	})
+
+	viewer := models.User{Interests: listPtr("Music", "Art"), MinSharedInterests: intPtr(2)}
+	softViewer := viewer
+	softViewer.SharedStrictness = strPtr(StrictnessSoft)
+	runSignalCases(t, InterestsSignal{PreferredMultiplier: 2}, []signalCase{
+		{name: "minimum met", viewer: viewer, target: models.User{Interests: listPtr("art", "music")}, wantValue: 2},
+		{name: "hard minimum missed", viewer: viewer, target: models.User{Interests: listPtr("Art")}, wantSkip: true},
+		{name: "soft minimum missed", viewer: softViewer, target: models.User{Interests: listPtr("Art")}, wantValue: 0},
+		{name: "soft nothing shared", viewer: softViewer, target: models.User{}, wantValue: -2},
+	})
+}
+
+func TestAttributesSignal(t *testing.T) {
+	viewer := models.User{AttributeFilters: map[string]*models.AttributeFilter{
+		"smoking": {Values: []string{"Never"}, Dealbreaker: true},
+		"height":  {Min: intPtr(170), Max: intPtr(190)},
+	}}
+
+	runSignalCases(t, AttributesSignal{}, []signalCase{
+		{name: "both satisfied", viewer: viewer, target: models.User{Attributes: map[string]interface{}{"smoking": "never", "height": 180.0}}, wantValue: 2},
+		{name: "soft filter failed", viewer: viewer, target: models.User{Attributes: map[string]interface{}{"smoking": "Never", "height": 200.0}}, wantValue: 1},
+		{name: "dealbreaker failed", viewer: viewer, target: models.User{Attributes: map[string]interface{}{"smoking": "Regularly", "height": 180.0}}, wantSkip: true},
+		{name: "dealbreaker missing", viewer: viewer, target: models.User{}, wantSkip: true},
+		{name: "no filters", viewer: models.User{}, target: models.User{}, wantValue: 0},
+	})
+}
+
+func TestActivitySignal(t *testing.T) {
+	now := time.Date(2025, 1, 15, 12, 0, 0, 0, time.UTC)
+	at := func(d time.Duration) models.User {
+		ts := now.Add(-d)
+		return models.User{LastActiveAt: &ts}
+	}
+	s := ActivitySignal{HalfLifeDays: 7, now: func() time.Time { return now }}
+
+	runSignalCases(t, s, []signalCase{
+		{name: "active now", target: at(0), wantValue: 1},
+		{name: "one half-life", target: at(7 * 24 * time.Hour), wantValue: 0.5},
+		{name: "two half-lives", target: at(14 * 24 * time.Hour), wantValue: 0.25},
+		{name: "unknown", target: models.User{}, wantValue: 0},
+	})
+}
+
+func TestNewcomerSignal(t *testing.T) {
+	now := time.Date(2025, 1, 15, 12, 0, 0, 0, time.UTC)
+	completed := func(d time.Duration) models.User {
+		ts := now.Add(-d)
+		return models.User{ProfileCompletedAt: &ts}
+	}
+	s := NewcomerSignal{WindowDays: 10, now: func() time.Time { return now }}
+
+	runSignalCases(t, s, []signalCase{
+		{name: "just joined", target: completed(0), wantValue: 1},
+		{name: "halfway through the window", target: completed(5 * 24 * time.Hour), wantValue: 0.5},
+		{name: "after the window", target: completed(30 * 24 * time.Hour), wantValue: 0},
+		{name: "unknown", target: models.User{}, wantValue: 0},
+	})
+}
+
+func TestStrategySkipsDormantTargets(t *testing.T) {
+	s := &Strategy{InactiveAfterDays: 30, Signals: []WeightedSignal{{Signal: GenderSignal{}, Weight: 1}}}
+	viewer := models.User{LookingForGender: strPtr("any")}
+	recent := time.Now().Add(-24 * time.Hour)
+	old := time.Now().Add(-60 * 24 * time.Hour)
+
+	if _, skip := s.Score(viewer, models.User{Gender: strPtr("female"), LastActiveAt: &recent}); skip {
+		t.Error("recently active target was skipped")
+	}
+	if _, skip := s.Score(viewer, models.User{Gender: strPtr("female"), LastActiveAt: &old}); !skip {
+		t.Error("dormant target was not skipped")
+	}
+	if _, skip := s.Score(viewer, models.User{Gender: strPtr("female")}); skip {
+		t.Error("target with unknown activity was skipped")
+	}
+	if e := s.Explain(viewer, models.User{Gender: strPtr("female"), LastActiveAt: &old}); !e.Skipped {
+		t.Error("explanation of a dormant target is not marked skipped")
+	}
+}
+
+func TestDistanceSignal(t *testing.T) {
+	tallinn := models.User{City: strPtr("Tallinn")}
+	tartu := models.User{City: strPtr("Tartu")}
+	km, _ := cityDistanceKm("Tallinn", "Tartu")
+
+	runSignalCases(t, DistanceSignal{MaxKm: 500}, []signalCase{
+		{name: "same city", viewer: tallinn, target: tallinn, wantValue: 1},
+		{name: "nearby city", viewer: tallinn, target: tartu, wantValue: 1 - km/500},
+		{name: "beyond max", viewer: tallinn, target: models.User{City: strPtr("Berlin")}, wantValue: 0},
+		{name: "unknown city", viewer: tallinn, target: models.User{City: strPtr("Atlantis")}, wantValue: 0},
+	})
+}
+
+func TestCollaborativeSignal(t *testing.T) {
+	target := models.User{
+		UserID:    7,
+		Birthdate: birthdateForAge(30),
+		Hobbies:   listPtr("Hiking", "Chess", "Cooking"),
+		Interests: listPtr("Art", "Travel"),
+	}
+	viewer := models.User{
+		LookingForMinAge: intPtr(25),
+		LookingForMaxAge: intPtr(35),
+		Hobbies:          listPtr("hiking", "chess", "cooking", "gaming"),
+		Interests:        listPtr("art"),
+	}
+	// out of the age range and without shared interests
+	older := target
+	older.Birthdate = birthdateForAge(50)
+	older.Interests = nil
+
+	runSignalCases(t, CollaborativeSignal{}, []signalCase{
+		{name: "liked by similar users", viewer: models.User{Affinities: map[int]float64{7: 1}}, target: target, wantValue: 1},
+		{name: "dismissed by similar users", viewer: models.User{Affinities: map[int]float64{7: -0.5}}, target: target, wantValue: 0.25},
+		{name: "affinity wins over cold start", viewer: models.User{Affinities: map[int]float64{7: -1}, Hobbies: viewer.Hobbies}, target: target, wantValue: 0},
+		// 2 for the age, 3 shared hobbies and 1 shared interest
+		{name: "cold start uses the classic score", viewer: viewer, target: target, wantValue: 0.75},
+		{name: "cold start leaves out excluded signals", viewer: viewer, target: older, wantValue: 0.375},
+		{name: "cold start without profile data", viewer: models.User{Affinities: map[int]float64{8: 1}}, target: target, wantValue: 0},
+	})
+}
+
+func TestUserSimilarities(t *testing.T) {
+	reactions := map[int]map[int]float64{
+		1: {10: ReactionLike, 11: ReactionLike, 12: ReactionDismiss},
+		2: {10: ReactionLike, 11: ReactionLike, 12: ReactionDismiss, 13: ReactionLike},
+		3: {10: ReactionDismiss, 11: ReactionDismiss},
+		4: {12: ReactionDismiss},
+	}
+	got := UserSimilarities(reactions, 2, 10)
+
+	if len(got[1]) != 2 || got[1][0].UserID != 2 || got[1][1].UserID != 4 {
+		t.Fatalf("neighbors of 1 = %+v, want 2 then 4", got[1])
+	}
+	if want := 3 / (math.Sqrt(3) * 2); math.Abs(got[1][0].Similarity-want) > 1e-9 {
+		t.Errorf("similarity(1, 2) = %v, want %v", got[1][0].Similarity, want)
+	}
+	// a single shared dismissal counts for half with minOverlap 2
+	if want := 1 / math.Sqrt(3) / 2; math.Abs(got[1][1].Similarity-want) > 1e-9 {
+		t.Errorf("similarity(1, 4) = %v, want %v", got[1][1].Similarity, want)
+	}
+	if len(got[3]) != 0 {
+		t.Errorf("users with opposite taste must not be neighbors, got %+v", got[3])
+	}
+	if top := UserSimilarities(reactions, 2, 1); len(top[1]) != 1 {
+		t.Errorf("topK 1 kept %d neighbors", len(top[1]))
+	}
+}
+
+func TestBioSignal(t *testing.T) {
+	hiker := models.User{BioVector: map[string]float64{"hiking": 2, "mountains": 1}}
+
+	runSignalCases(t, BioSignal{}, []signalCase{
+		{name: "same bio", viewer: hiker, target: hiker, wantValue: 1},
+		{name: "unrelated bio", viewer: hiker, target: models.User{BioVector: map[string]float64{"chess": 1}}, wantValue: 0},
+		{name: "empty bio", viewer: hiker, target: models.User{}, wantValue: 0},
+	})
+}
+
+func TestCompatibility(t *testing.T) {
+	a := map[int]models.QuestionAnswer{
+		1: {Answer: "Yes", Acceptable: []string{"Yes"}, Importance: ImportanceVery},
+		2: {Answer: "Rarely", Acceptable: []string{"Rarely", "Sometimes"}, Importance: ImportanceSomewhat},
+		3: {Answer: "No", Acceptable: []string{"No"}, Importance: ImportanceMandatory},
+	}
+	b := map[int]models.QuestionAnswer{
+		1: {Answer: "yes", Acceptable: []string{"Yes", "Not sure"}, Importance: ImportanceSomewhat},
+		2: {Answer: "Almost every weekend", Acceptable: []string{"Almost every weekend", "Sometimes", "Rarely"}, Importance: ImportanceLittle},
+	}
+
+	// a earns 50 of 60 points, b 11 of 11; question 3 is not shared
+	match, common := Compatibility(a, b)
+	if common != 2 {
+		t.Fatalf("common = %d, want 2", common)
+	}
+	if want := math.Sqrt(50.0/60) - 0.5; math.Abs(match-want) > 1e-9 {
+		t.Fatalf("match = %v, want %v", match, want)
+	}
+	if back, _ := Compatibility(b, a); math.Abs(back-match) > 1e-9 {
+		t.Fatalf("match is not symmetric: %v vs %v", back, match)
+	}
+
+	if match, common := Compatibility(a, nil); match != 0 || common != 0 {
+		t.Fatalf("no shared questions: got %v, %d", match, common)
+	}
+
+	indifferent := map[int]models.QuestionAnswer{
+		1: {Answer: "No", Acceptable: []string{"No"}, Importance: ImportanceIrrelevant},
+		2: {Answer: "Sometimes", Acceptable: []string{"Sometimes"}, Importance: ImportanceIrrelevant},
+	}
+	// a earns 10 of 60 points: below the margin of error, floored at 0
+	if match, _ := Compatibility(a, indifferent); match != 0 {
+		t.Fatalf("match = %v, want 0", match)
+	}
+	// the indifferent side alone cannot lower the match
+	if match, _ := Compatibility(indifferent, indifferent); math.Abs(match-0.5) > 1e-9 {
+		t.Fatalf("match = %v, want 0.5", match)
+	}
+
+	runSignalCases(t, CompatibilitySignal{}, []signalCase{
+		{name: "answers in common", viewer: models.User{Answers: a}, target: models.User{Answers: b}, wantValue: math.Sqrt(50.0/60) - 0.5},
+		{name: "nothing in common", viewer: models.User{Answers: a}, target: models.User{}, wantValue: 0},
+	})
+}
+
+func TestProfileSimilarity(t *testing.T) {
+	a := models.User{Hobbies: listPtr("hiking", "chess"), Interests: listPtr("music"), Birthdate: birthdateForAge(30)}
+	b := models.User{Hobbies: listPtr("Hiking"), Interests: listPtr("music", "art"), Birthdate: birthdateForAge(35)}
+
+	// 2 shared of 4 distinct items, 5 years apart
+	if got, want := ProfileSimilarity(a, b), (2*0.5+0.5)/3; math.Abs(got-want) > 1e-9 {
+		t.Errorf("ProfileSimilarity = %v, want %v", got, want)
+	}
+	if got := ProfileSimilarity(a, a); math.Abs(got-1) > 1e-9 {
+		t.Errorf("ProfileSimilarity with itself = %v, want 1", got)
+	}
+}
+
+func TestDiversityRerank(t *testing.T) {
+	hiker := func(id int) models.User {
+		return models.User{UserID: id, Hobbies: listPtr("hiking"), Birthdate: birthdateForAge(30)}
+	}
+	items := []Ranked{
+		{User: hiker(1), Score: 10},
+		{User: hiker(2), Score: 9.5},
+		{User: models.User{UserID: 3, Hobbies: listPtr("chess"), Birthdate: birthdateForAge(45)}, Score: 9},
+	}
+	ids := func(ranked []Ranked) []int {
+		var out []int
+		for _, r := range ranked {
+			out = append(out, r.User.UserID)
+		}
+		return out
+	}
+
+	if got := ids(DiversityRerank(items, 3, 1)); got[0] != 1 || got[1] != 2 || got[2] != 3 {
+		t.Errorf("lambda 1 order = %v, want score order", got)
+	}
+	if got := ids(DiversityRerank(items, 2, 0.5)); got[0] != 1 || got[1] != 3 {
+		t.Errorf("lambda 0.5 picked %v, want the dissimilar candidate second", got)
+	}
+	if got := DiversityRerank(items, 5, 0.5); len(got) != 3 {
+		t.Errorf("k beyond len returned %d items", len(got))
+	}
+}
+
+func TestClassicStrategyMatchesOriginalWeights(t *testing.T) {
+	s, err := BuildStrategy("classic_one_sided", DefaultConfig().Strategies["classic_one_sided"])
+	if err != nil {
+		t.Fatal(err)
+	}
+	viewer := models.User{
+		Country: strPtr("Estonia"), City: strPtr("Tallinn"),
+		LookingForGender: strPtr("female"), LookingForMinAge: intPtr(20), LookingForMaxAge: intPtr(40),
+		Hobbies: listPtr("Reading", "Cooking"), PreferredHobbies: listPtr("Cooking"),
+		Interests: listPtr("Music"),
+	}
+	target := models.User{
+		Country: strPtr("Estonia"), City: strPtr("Tallinn"), Gender: strPtr("female"),
+		Birthdate: birthdateForAge(30),
+		Hobbies:   listPtr("Reading", "Cooking"), Interests: listPtr("Music"),
+	}
+
+	// 1 country + 2 city + 2 age + 2 gender + (1 + 2) hobbies + 1 interest
+	score, skip := s.Score(viewer, target)
+	if skip || score != 11 {
+		t.Fatalf("Score = %v, %v; want 11, false", score, skip)
+	}
+
+	// activity plays no part in the original score
+	now := time.Now()
+	target.LastActiveAt = &now
+	if score, _ := s.Score(viewer, target); score != 11 {
+		t.Fatalf("active target: Score = %v, want 11", score)
+	}
+	if s.InactiveAfterDays != 0 {
+		t.Errorf("InactiveAfterDays = %v, want 0", s.InactiveAfterDays)
+	}
+
+	target.LastActiveAt = nil
+	target.Hobbies = nil
+	target.Interests = nil
+	if score, skip := s.Score(viewer, target); !skip || score != 7 {
+		t.Fatalf("below threshold: Score = %v, %v; want 7, true", score, skip)
+	}
+}
+
+func TestReciprocalStrategy(t *testing.T) {
+	s, err := BuildStrategy("classic", DefaultConfig().Strategies["classic"])
+	if err != nil {
+		t.Fatal(err)
+	}
+	viewer := models.User{
+		Country: strPtr("Estonia"), City: strPtr("Tallinn"), Gender: strPtr("male"),
+		Birthdate:        birthdateForAge(30),
+		LookingForGender: strPtr("female"), LookingForMinAge: intPtr(20), LookingForMaxAge: intPtr(40),
+		Hobbies: listPtr("Reading", "Cooking"), PreferredHobbies: listPtr("Cooking"),
+	}
+	target := models.User{
+		Country: strPtr("Estonia"), City: strPtr("Tallinn"), Gender: strPtr("female"),
+		Birthdate:        birthdateForAge(28),
+		LookingForGender: strPtr("male"), LookingForMinAge: intPtr(25), LookingForMaxAge: intPtr(35),
+		Hobbies: listPtr("Reading", "Cooking"),
+	}
+
+	// viewer -> target: 3 location + 2 age + 2 gender + 3 hobbies = 10
+	// target -> viewer: 3 location + 2 age + 2 gender + 2 hobbies = 9
+	score, skip := s.Score(viewer, target)
+	if want := HarmonicMean(10, 9); skip || math.Abs(score-want) > 1e-9 {
+		t.Fatalf("Score = %v, %v; want %v, false", score, skip, want)
+	}
+	if s.InactiveAfterDays != defaultInactiveAfterDays {
+		t.Errorf("InactiveAfterDays = %v, want %v", s.InactiveAfterDays, defaultInactiveAfterDays)
+	}
+
+	// + 1 each way for someone active right now
+	now := time.Now()
+	active := target
+	active.LastActiveAt = &now
+	viewer.LastActiveAt = &now
+	if score, _ := s.Score(viewer, active); math.Abs(score-HarmonicMean(11, 10)) > 1e-6 {
+		t.Errorf("active users: Score = %v, want %v", score, HarmonicMean(11, 10))
+	}
+	viewer.LastActiveAt = nil
+
+	cases := []struct {
+		name   string
+		modify func(u *models.User)
+	}{
+		{name: "target wants another gender", modify: func(u *models.User) { u.LookingForGender = strPtr("female") }},
+		{name: "viewer too old for target", modify: func(u *models.User) { u.LookingForMaxAge = intPtr(29) }},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			tgt := target
+			tc.modify(&tgt)
+			if _, skip := s.Score(viewer, tgt); !skip {
+				t.Fatal("expected the target to be skipped")
+			}
+			oneSided := *s
+			oneSided.Reciprocal = false
+			if _, skip := oneSided.Score(viewer, tgt); skip {
+				t.Fatal("one-sided scoring should still accept the target")
+			}
+		})
+	}
+}
+
+func TestHarmonicMean(t *testing.T) {
+	cases := []struct {
+		a, b, want float64
+	}{
+		{a: 10, b: 10, want: 10},
+		{a: 12, b: 6, want: 8},
+		{a: 5, b: 0, want: 0},
+	}
+	for _, tc := range cases {
+		if got := HarmonicMean(tc.a, tc.b); math.Abs(got-tc.want) > 1e-9 {
+			t.Errorf("HarmonicMean(%v, %v) = %v, want %v", tc.a, tc.b, got, tc.want)
+		}
+	}
+}
+
+func TestBuildStrategyRejectsUnknownSignal(t *testing.T) {
+	_, err := BuildStrategy("bad", StrategyConfig{Signals: map[string]SignalConfig{"horoscope": {Weight: 1}}})
+	if err == nil {
+		t.Fatal("expected an error for an unknown signal")
+	}
+}
+
+func TestExperimentBucket(t *testing.T) {
+	e := &ExperimentConfig{Name: "reciprocal", Variants: []VariantConfig{
+		{Name: "control", Strategy: "classic", Weight: 3},
+		{Name: "one_sided", Strategy: "classic_one_sided", Weight: 1},
+	}}
+
+	counts := map[string]int{}
+	for id := 1; id <= 4000; id++ {
+		v := e.Bucket(id)
+		if again := e.Bucket(id); again.Name != v.Name {
+			t.Fatalf("user %d moved from %s to %s", id, v.Name, again.Name)
+		}
+		counts[v.Name]++
+	}
+	// roughly a 3:1 split
+	if counts["one_sided"] < 800 || counts["one_sided"] > 1200 {
+		t.Errorf("one_sided got %d of 4000 users, want about 1000", counts["one_sided"])
+	}
+
+	built := map[string]*Strategy{"classic": {}, "classic_one_sided": {}}
+	if err := validateExperiment(e, built); err != nil {
+		t.Errorf("valid experiment rejected: %v", err)
+	}
+	e.Variants[1].Strategy = "missing"
+	if err := validateExperiment(e, built); err == nil {
+		t.Error("expected an error for an unknown strategy")
+	}
+}
@@ -0,0 +1,24 @@
+package text
+
+// words in a bio that hint at a hobby or interest, besides its own name, in
+// the languages spoken in the supported countries; a trailing '*' matches any
+// word starting with the rest
+// English stems are spelled out as whole words, since their prefixes also
+// start unrelated words ("single", "ready", "painful", "dogma"); prefixes are
+// kept only where no such word is known
+var TagKeywords = map[string][]string{
+	"Reading":     {"read", "reads", "reading", "reader", "readers", "book", "books", "bookworm", "bookish", "novel", "novels", "novelist", "lesen", "buch", "bücher", "leer", "libro", "libros", "raamat*", "lugemine"},
+	"Gaming":      {"game", "games", "gamer", "gamers", "videogame*", "playstation", "xbox", "nintendo", "spiele*", "juego*", "mängi*"},
+	"Cooking":     {"cook", "cooks", "cooking", "cooked", "bake", "bakes", "baking", "baker", "chef", "recipe*", "kochen", "cocina*", "kokka*", "küpseta*"},
+	"Art":         {"paint", "paints", "painting", "paintings", "painter", "draw", "drawing", "drawings", "sketch", "sketches", "sketching", "museum*", "gallery", "kunst", "arte", "maali*"},
+	"Sports":      {"sport*", "football", "soccer", "basketball", "tennis", "running", "gym", "fitness", "fußball", "fútbol", "deporte*", "jalgpall*", "trenn*"},
+	"Music":       {"music*", "guitar*", "piano", "concert*", "sing", "sings", "singing", "singer", "singers", "band", "musik", "música", "muusika*", "kontsert*"},
+	"Travel":      {"travel*", "trip", "trips", "backpack*", "abroad", "reisen", "viaje*", "viajar", "reisi*"},
+	"Photography": {"photo*", "camera*", "kamera", "fotograf*", "foto*", "pildista*"},
+	"Movies":      {"movie*", "film*", "cinema", "netflix", "kino", "cine", "película*"},
+	"Coding":      {"code", "codes", "coding", "coder", "coders", "program", "programs", "programming", "programmer", "programmers", "developer", "software", "programmieren", "programmierer", "programar", "programación", "programador", "programmee*", "arendaja"},
+	"Nature":      {"hike", "hikes", "hiking", "hiker", "nature", "forest*", "mountain*", "camping", "outdoor*", "natur", "wandern", "naturaleza", "loodus*", "matka*"},
+	"Pets":        {"dog", "dogs", "doggo", "cat", "cats", "puppy", "kitten*", "hund", "katze*", "perro*", "gato*", "koer*", "kass*"},
+	// not "drama" ("no drama") or "stage" ("at this stage")
+	"Theatre": {"theatre", "theater*", "teatro", "teater*", "musicals"},
+}
@@ -0,0 +1,46 @@
+package text
+
+import "strings"
+
+// common words of the languages spoken in the supported countries:
+// English, German, Spanish and Estonian
+var stopwords = buildStopwords(
+	// English
+	`about above after again against all also and any are aren't because been before being below
+	between both but can can't cannot could couldn't did didn't does doesn't doing don't down during
+	each few for from further had hadn't has hasn't have haven't having her here here's hers herself
+	him himself his how how's i'm i've into isn't it's its itself just let's like love more most
+	mustn't myself nor not off once only other ought our ours ourselves out over own really same
+	shan't she she'd she'll she's should shouldn't some such than that that's the their theirs them
+	themselves then there there's these they they'd they'll they're they've this those through too
+	under until very was wasn't we'd we'll we're we've were weren't what what's when when's where
+	where's which while who who's whom why why's will with won't would wouldn't you you'd you'll
+	you're you've your yours yourself yourselves`,
+	// German
+	`aber alle allem allen aller alles als also andere anderen auch auf aus bei beim bin bis bist
+	dann das dass dein deine dem den der des dich die dir doch dort durch ein eine einem einen einer
+	eines euch euer für gegen habe haben hat hatte ich ihm ihn ihr ihre im in ist jede jedem jeden
+	jeder jedes jetzt kann kein keine mein meine mich mir mit muss nach nicht noch nur oder ohne
+	sehr sein seine sich sie sind über und uns unser unter viel vom von vor war waren was weil wenn
+	wer wie wir wird zum zur zwischen gerne`,
+	// Spanish
+	`al algo algunos ante antes como con contra cual cuando del desde donde durante ella ellas
+	ellos entre era eres esa esas ese eso esos esta estas este esto estos fue gran hay las les
+	los más mis mucho muy nada nos nosotros otra otro para pero poco por porque que quien sin
+	sobre son soy también tanto tengo tiene todo todos una uno unos usted vosotros yo`,
+	// Estonian
+	`aga ainult alla all alt enne et ega ehk eks ise just kas kes keegi kuhu kui kuid kus kõik
+	ma meie mida miks mille mina minu mis mu mul mulle nad nagu nemad nii nüüd oled olen oli
+	olla oma on pole sa sina see seda selle sest siin siis sinu su ta tema teie teda tõttu ja
+	ka ning väga veel üle`,
+)
+
+func buildStopwords(lists ...string) map[string]bool {
+	set := make(map[string]bool)
+	for _, list := range lists {
+		for _, w := range strings.Fields(list) {
+			set[w] = true
+		}
+	}
+	return set
+}
@@ -0,0 +1,100 @@
+// Package text turns free-text bios into weighted terms for comparing them.
+package text
+
+import (
+	"math"
+	"strings"
+	"unicode"
+)
+
+// shortest token kept; shorter ones are mostly noise
+const minTokenLength = 3
+
+// lowercases the text and splits it into words, dropping stopwords,
+// numbers and very short words
+func Tokenize(s string) []string {
+	words := strings.FieldsFunc(strings.ToLower(s), func(r rune) bool {
+		return !unicode.IsLetter(r) && r != '\''
+	})
+	tokens := make([]string, 0, len(words))
+	for _, w := range words {
+		w = strings.Trim(w, "'")
+		if len([]rune(w)) < minTokenLength || stopwords[w] {
+			continue
+		}
+		tokens = append(tokens, w)
+	}
+	return tokens
+}
+
+// how often each token occurs
+func TermCounts(tokens []string) map[string]int {
+	counts := make(map[string]int, len(tokens))
+	for _, t := range tokens {
+		counts[t]++
+	}
+	return counts
+}
+
+// the TF-IDF weight of a term occurring count times in a bio, when
+// documentCount of totalDocuments bios contain it; both factors are dampened
+// so long bios and rare typos do not dominate
+func TFIDF(count, documentCount, totalDocuments int) float64 {
+	if count <= 0 {
+		return 0
+	}
+	tf := 1 + math.Log(float64(count))
+	idf := math.Log(float64(1+totalDocuments)/float64(1+documentCount)) + 1
+	return tf * idf
+}
+
+// cosine of the angle between two sparse vectors, 0 when either is empty
+func Cosine(a, b map[string]float64) float64 {
+	if len(a) > len(b) {
+		a, b = b, a
+	}
+	var dot, normA, normB float64
+	for term, w := range a {
+		dot += w * b[term]
+		normA += w * w
+	}
+	for _, w := range b {
+		normB += w * w
+	}
+	if normA == 0 || normB == 0 {
+		return 0
+	}
+	return dot / math.Sqrt(normA*normB)
+}
+
+// the tags whose keywords appear in the text, in the order of tags
+// keywords match whole tokens, or prefixes when they end with '*'
+func SuggestTags(s string, tags []string, keywords map[string][]string) []string {
+	tokens := make(map[string]bool)
+	for _, t := range strings.FieldsFunc(strings.ToLower(s), func(r rune) bool { return !unicode.IsLetter(r) }) {
+		tokens[t] = true
+	}
+
+	var found []string
+	for _, tag := range tags {
+		if matchesAny(tokens, append([]string{strings.ToLower(tag)}, keywords[tag]...)) {
+			found = append(found, tag)
+		}
+	}
+	return found
+}
+
+func matchesAny(tokens map[string]bool, keywords []string) bool {
+	for _, k := range keywords {
+		if prefix, ok := strings.CutSuffix(k, "*"); ok {
+			for t := range tokens {
+				if strings.HasPrefix(t, prefix) {
+					return true
+				}
+			}
+		} else if tokens[k] {
+			return true
+		}
+	}
+	return false
+}
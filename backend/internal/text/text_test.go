@@ -0,0 +1,75 @@
+package text
+
+import (
+	"math"
+	"reflect"
+	"testing"
+)
+
+func TestTokenize(t *testing.T) {
+	got := Tokenize("I love hiking, and Hiking again! Ich wandere gerne in Tartu. 2024 ok")
+	want := []string{"hiking", "hiking", "wandere", "tartu"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Tokenize = %v, want %v", got, want)
+	}
+}
+
+func TestTFIDF(t *testing.T) {
+	// a term in every document still counts a little, a rarer one counts more
+	common := TFIDF(1, 10, 10)
+	rare := TFIDF(1, 1, 10)
+	if common <= 0 || rare <= common {
+		t.Errorf("TFIDF common = %v, rare = %v; want 0 < common < rare", common, rare)
+	}
+	if TFIDF(2, 1, 10) <= rare {
+		t.Error("a repeated term should weigh more")
+	}
+}
+
+func TestCosine(t *testing.T) {
+	a := map[string]float64{"hiking": 1, "chess": 1}
+	if got := Cosine(a, a); math.Abs(got-1) > 1e-9 {
+		t.Errorf("Cosine(a, a) = %v, want 1", got)
+	}
+	if got := Cosine(a, map[string]float64{"cooking": 2}); got != 0 {
+		t.Errorf("Cosine of disjoint vectors = %v, want 0", got)
+	}
+	if got, want := Cosine(a, map[string]float64{"hiking": 3}), 1/math.Sqrt(2); math.Abs(got-want) > 1e-9 {
+		t.Errorf("Cosine = %v, want %v", got, want)
+	}
+	if got := Cosine(a, nil); got != 0 {
+		t.Errorf("Cosine with an empty vector = %v, want 0", got)
+	}
+}
+
+func TestSuggestTags(t *testing.T) {
+	keywords := map[string][]string{
+		"Reading":     {"book*", "novels"},
+		"Photography": {"camera", "photo*"},
+	}
+	got := SuggestTags("Always carrying a camera and a few Books.", []string{"Gaming", "Reading", "Photography"}, keywords)
+	want := []string{"Reading", "Photography"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("SuggestTags = %v, want %v", got, want)
+	}
+	if got := SuggestTags("I like gaming", []string{"Gaming"}, nil); len(got) != 1 {
+		t.Errorf("the tag itself should match, got %v", got)
+	}
+
+	tags := []string{"Reading", "Cooking", "Art", "Music", "Travel", "Coding", "Pets", "Theatre"}
+	for _, tc := range []struct {
+		bio  string
+		want []string
+	}{
+		{"Single and ready to mingle, no drama please", nil},
+		{"Painful booking, a triple espresso and some dogma at this stage", nil},
+		{"Back from a long codependent week", nil},
+		{"I love singing, reading novels and the theatre", []string{"Reading", "Music", "Theatre"}},
+		{"Baking for my dogs after a road trip", []string{"Cooking", "Travel", "Pets"}},
+	} {
+		got := SuggestTags(tc.bio, tags, TagKeywords)
+		if !reflect.DeepEqual(got, tc.want) {
+			t.Errorf("SuggestTags(%q) = %v, want %v", tc.bio, got, tc.want)
+		}
+	}
+}
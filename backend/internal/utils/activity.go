@@ -0,0 +1,48 @@
+package utils
+
+import (
+	"context"
+	"log"
+	"sync"
+	"time"
+
+	"matchme-backend/internal/db"
+)
+
+// last_active_at is written at most this often per user
+const activityResolution = time.Minute
+
+var (
+	lastTouched      = make(map[int]time.Time)
+	lastPruned       time.Time
+	lastTouchedMutex sync.Mutex
+)
+
+// records that the user is active now
+func TouchLastActive(userID int) {
+	now := time.Now()
+	lastTouchedMutex.Lock()
+	if now.Sub(lastTouched[userID]) < activityResolution {
+		lastTouchedMutex.Unlock()
+		return
+	}
+	lastTouched[userID] = now
+	// entries older than the resolution no longer hold anything back, so the
+	// map only keeps the users seen in the last minute or so
+	if now.Sub(lastPruned) >= activityResolution {
+		for id, t := range lastTouched {
+			if now.Sub(t) >= activityResolution {
+				delete(lastTouched, id)
+			}
+		}
+		lastPruned = now
+	}
+	lastTouchedMutex.Unlock()
+
+	_, err := db.Pool.Exec(context.Background(), `
+        UPDATE users SET last_active_at = NOW() WHERE id = $1
+    `, userID)
+	if err != nil {
+		log.Printf("Error updating last_active_at for %d: %v\n", userID, err)
+	}
+}
@@ -1,7 +1,6 @@
 package utils
 
 import (
-	"context"
 	"errors"
 	"fmt"
 	"net/http"
@@ -9,8 +8,6 @@ import (
 
 	"golang.org/x/crypto/bcrypt"
 
-	"matchme-backend/internal/db"
-
 	"github.com/golang-jwt/jwt/v4"
 )
 
@@ -18,17 +15,19 @@ var jwtKey = []byte("your_secret_key")
 
 // creates a JWT with userID and email claims
 func GenerateToken(userID int, email string) (string, error) {
+	now := time.Now()
 	claims := jwt.MapClaims{
 		"userID": userID,
 		"email":  email,
-		"exp":    time.Now().Add(24 * time.Hour).Unix(),
+		"iat":    now.Unix(),
+		"exp":    now.Add(24 * time.Hour).Unix(),
 	}
 	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
 	return token.SignedString(jwtKey)
 }
 
-// parses a token string and returns the userID claim
-func ExtractUserIDFromToken(tokenString string) (string, error) {
+// parses and verifies a token string
+func parseClaims(tokenString string) (jwt.MapClaims, error) {
 	token, err := jwt.Parse(tokenString, func(token *jwt.Token) (interface{}, error) {
 		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
 			return nil, errors.New("unexpected signing method")
@@ -36,19 +35,41 @@ func ExtractUserIDFromToken(tokenString string) (string, error) {
 		return jwtKey, nil
 	})
 	if err != nil {
-		return "", err
+		return nil, err
 	}
 	if claims, ok := token.Claims.(jwt.MapClaims); ok && token.Valid {
-		switch v := claims["userID"].(type) {
-		case float64:
-			return fmt.Sprintf("%d", int(v)), nil
-		case string:
-			return v, nil
-		default:
-			return "", errors.New("invalid userID type in token")
-		}
+		return claims, nil
+	}
+	return nil, errors.New("invalid token")
+}
+
+// parses a token string and returns the userID claim
+func ExtractUserIDFromToken(tokenString string) (string, error) {
+	claims, err := parseClaims(tokenString)
+	if err != nil {
+		return "", err
+	}
+	switch v := claims["userID"].(type) {
+	case float64:
+		return fmt.Sprintf("%d", int(v)), nil
+	case string:
+		return v, nil
+	default:
+		return "", errors.New("invalid userID type in token")
 	}
-	return "", errors.New("invalid token")
+}
+
+// returns when the token was issued; tokens from before the iat claim was
+// added report the zero time
+func tokenIssuedAt(tokenString string) (time.Time, error) {
+	claims, err := parseClaims(tokenString)
+	if err != nil {
+		return time.Time{}, err
+	}
+	if iat, ok := claims["iat"].(float64); ok {
+		return time.Unix(int64(iat), 0), nil
+	}
+	return time.Time{}, nil
 }
 
 // retrieves the token from a cookie
@@ -60,6 +81,16 @@ func ExtractUserIDFromTokenFromCookie(r *http.Request) (string, error) {
 	return ExtractUserIDFromToken(cookie.Value)
 }
 
+// removes the token cookie from the browser
+func ClearTokenCookie(w http.ResponseWriter) {
+	http.SetCookie(w, &http.Cookie{
+		Name:   "token",
+		Value:  "",
+		Path:   "/",
+		MaxAge: -1,
+	})
+}
+
 // returns the bcrypt hash of the plaintext password
 func HashPassword(plain string) (string, error) {
 	bytes, err := bcrypt.GenerateFromPassword([]byte(plain), bcrypt.DefaultCost)
@@ -73,29 +104,3 @@ func HashPassword(plain string) (string, error) {
 func ComparePassword(hashedPassword, plain string) error {
 	return bcrypt.CompareHashAndPassword([]byte(hashedPassword), []byte(plain))
 }
-
-// checks if a user's profile is complete
-func IsProfileComplete(userID int) (bool, error) {
-	var count int
-	err := db.Pool.QueryRow(context.Background(), `
-        SELECT COUNT(*)
-        FROM users
-        WHERE id = $1
-        AND fname IS NOT NULL
-        AND surname IS NOT NULL
-        AND gender IS NOT NULL
-        AND birthdate IS NOT NULL
-        AND about IS NOT NULL
-        AND hobbies IS NOT NULL
-        AND interests IS NOT NULL
-        AND country IS NOT NULL
-        AND city IS NOT NULL
-        AND looking_for_gender IS NOT NULL
-        AND looking_for_min_age IS NOT NULL
-        AND looking_for_max_age IS NOT NULL
-    `, userID).Scan(&count)
-	if err != nil {
-		return false, err
-	}
-	return count > 0, nil
-}
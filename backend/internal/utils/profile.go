@@ -0,0 +1,136 @@
+package utils
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"matchme-backend/internal/db"
+)
+
+// a profile field that has to be filled in before the user can use matching
+type ProfileField struct {
+	Name   string // JSON field name exposed to clients
+	Column string // column in the users table
+}
+
+// the single definition of what a "complete" profile is
+var RequiredProfileFields = []ProfileField{
+	{Name: "fname", Column: "fname"},
+	{Name: "surname", Column: "surname"},
+	{Name: "gender", Column: "gender"},
+	{Name: "birthdate", Column: "birthdate"},
+	{Name: "about", Column: "about"},
+	{Name: "hobbies", Column: "hobbies"},
+	{Name: "interests", Column: "interests"},
+	{Name: "country", Column: "country"},
+	{Name: "city", Column: "city"},
+	{Name: "looking_for_gender", Column: "looking_for_gender"},
+	{Name: "looking_for_min_age", Column: "looking_for_min_age"},
+	{Name: "looking_for_max_age", Column: "looking_for_max_age"},
+}
+
+// summary of which required fields a user has filled in
+type ProfileCompleteness struct {
+	Complete   bool     `json:"complete"`
+	Percentage int      `json:"percentage"`
+	Missing    []string `json:"missing"`
+}
+
+// returns an SQL condition that holds when every required field is set
+// alias qualifies the columns (e.g. "u") and may be empty
+func CompleteProfileCondition(alias string) string {
+	prefix := ""
+	if alias != "" {
+		prefix = alias + "."
+	}
+	conds := make([]string, len(RequiredProfileFields))
+	for i, f := range RequiredProfileFields {
+		conds[i] = prefix + f.Column + " IS NOT NULL"
+	}
+	return "(" + strings.Join(conds, " AND ") + ")"
+}
+
+// checks if a user's profile is complete
+func IsProfileComplete(userID int) (bool, error) {
+	c, err := GetProfileCompleteness(userID)
+	if err != nil {
+		return false, err
+	}
+	return c.Complete, nil
+}
+
+// reports the completion percentage and the missing required fields
+func GetProfileCompleteness(userID int) (ProfileCompleteness, error) {
+	cols := make([]string, len(RequiredProfileFields))
+	for i, f := range RequiredProfileFields {
+		cols[i] = f.Column + " IS NULL"
+	}
+
+	isNull := make([]bool, len(RequiredProfileFields))
+	dest := make([]interface{}, len(isNull))
+	for i := range isNull {
+		dest[i] = &isNull[i]
+	}
+
+	err := db.Pool.QueryRow(context.Background(),
+		"SELECT "+strings.Join(cols, ", ")+" FROM users WHERE id = $1", userID,
+	).Scan(dest...)
+	if err != nil {
+		return ProfileCompleteness{}, err
+	}
+
+	missing := []string{}
+	for i, f := range RequiredProfileFields {
+		if isNull[i] {
+			missing = append(missing, f.Name)
+		}
+	}
+	total := len(RequiredProfileFields)
+	return ProfileCompleteness{
+		Complete:   len(missing) == 0,
+		Percentage: (total - len(missing)) * 100 / total,
+		Missing:    missing,
+	}, nil
+}
+
+// stamps profile_completed_at the first time the profile becomes complete
+func MarkProfileCompleted(userID int) error {
+	_, err := db.Pool.Exec(context.Background(), `
+        UPDATE users
+        SET profile_completed_at = NOW()
+        WHERE id = $1
+          AND profile_completed_at IS NULL
+          AND `+CompleteProfileCondition("")+`
+    `, userID)
+	return err
+}
+
+// stamps profile_completed_at with the signup date for profiles that were
+// complete before the column existed
+func BackfillProfileCompletedAt() error {
+	_, err := db.Pool.Exec(context.Background(), `
+        UPDATE users
+        SET profile_completed_at = created_at
+        WHERE profile_completed_at IS NULL
+          AND `+CompleteProfileCondition("")+`
+    `)
+	return err
+}
+
+// returns the age in full years for a YYYY-MM-DD birthdate, or 0 if it cannot be parsed
+func CalcAge(birthdate string) int {
+	t, err := time.Parse("2006-01-02", birthdate)
+	if err != nil {
+		return 0
+	}
+	now := time.Now()
+	years := now.Year() - t.Year()
+	if (now.Month() < t.Month()) || (now.Month() == t.Month() && now.Day() < t.Day()) {
+		years--
+	}
+	if years < 0 {
+		years = 0
+	}
+	return years
+}
@@ -0,0 +1,106 @@
+package utils
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"matchme-backend/internal/db"
+)
+
+// returned for tokens issued before the user's sessions were revoked
+var ErrSessionRevoked = errors.New("session revoked")
+
+// a suspension or permanent ban in force on an account
+type Sanction struct {
+	Banned bool
+	Reason *string
+	Until  *time.Time // end of a suspension, nil for bans
+}
+
+// authenticates a token: returns the user and, when they are suspended or
+// banned, the sanction that keeps them out
+func AuthenticateToken(tokenString string) (int, *Sanction, error) {
+	userIDStr, err := ExtractUserIDFromToken(tokenString)
+	if err != nil {
+		return 0, nil, err
+	}
+	userID, err := strconv.Atoi(userIDStr)
+	if err != nil {
+		return 0, nil, err
+	}
+	issuedAt, err := tokenIssuedAt(tokenString)
+	if err != nil {
+		return 0, nil, err
+	}
+
+	sanction, validAfter, err := loadAccountStatus(userID)
+	if err != nil {
+		return 0, nil, err
+	}
+	if sanction != nil {
+		return userID, sanction, nil
+	}
+	// iat only has second precision
+	if validAfter != nil && issuedAt.Before(validAfter.Truncate(time.Second)) {
+		return 0, nil, ErrSessionRevoked
+	}
+	return userID, nil, nil
+}
+
+// authenticates the token cookie of a request, see AuthenticateToken
+func AuthenticateRequest(r *http.Request) (int, *Sanction, error) {
+	cookie, err := r.Cookie("token")
+	if err != nil {
+		return 0, nil, err
+	}
+	return AuthenticateToken(cookie.Value)
+}
+
+// returns the sanction in force on the user, nil for none
+func ActiveSanction(userID int) (*Sanction, error) {
+	sanction, _, err := loadAccountStatus(userID)
+	return sanction, err
+}
+
+func loadAccountStatus(userID int) (*Sanction, *time.Time, error) {
+	var (
+		bannedAt, suspendedUntil, validAfter *time.Time
+		banReason, suspensionReason          *string
+	)
+	err := db.Pool.QueryRow(context.Background(), `
+        SELECT banned_at, ban_reason, suspended_until, suspension_reason, sessions_valid_after
+        FROM users WHERE id = $1
+    `, userID).Scan(&bannedAt, &banReason, &suspendedUntil, &suspensionReason, &validAfter)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	switch {
+	case bannedAt != nil:
+		return &Sanction{Banned: true, Reason: banReason}, validAfter, nil
+	case suspendedUntil != nil && suspendedUntil.After(time.Now()):
+		return &Sanction{Reason: suspensionReason, Until: suspendedUntil}, validAfter, nil
+	}
+	return nil, validAfter, nil
+}
+
+// responds 403 with the reason and, for a suspension, when it ends
+func WriteSanction(w http.ResponseWriter, s *Sanction) {
+	resp := map[string]interface{}{
+		"error":           "Your account has been suspended.",
+		"reason":          s.Reason,
+		"suspended_until": nil,
+	}
+	if s.Banned {
+		resp["error"] = "Your account has been banned."
+	} else {
+		resp["suspended_until"] = s.Until.UTC().Format(time.RFC3339)
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusForbidden)
+	json.NewEncoder(w).Encode(resp)
+}
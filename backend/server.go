@@ -1,12 +1,20 @@
 package main
 
 import (
+	"flag"
+	"fmt"
 	"log"
 	"net/http"
+	"os"
+	"time"
 
 	"matchme-backend/internal/db"
+	"matchme-backend/internal/eval"
 	"matchme-backend/internal/handlers"
+	"matchme-backend/internal/jobs"
 	"matchme-backend/internal/middleware"
+	"matchme-backend/internal/scoring"
+	"matchme-backend/internal/utils"
 )
 
 // adds CORS headers to the response
@@ -31,13 +39,107 @@ func enableCORS(h http.Handler) http.Handler {
 	})
 }
 
-func main() {
-	// Initialize database connection
+// runs a maintenance command, e.g. `go run server.go rebuild-recommendations`
+func runCommand(args []string) {
+	switch args[0] {
+	case "rebuild-recommendations":
+		openDatabase()
+		defer db.CloseDB()
+		n, err := handlers.RebuildAllRecommendations()
+		if err != nil {
+			log.Fatalf("Failed to rebuild recommendations: %v\n", err)
+		}
+		log.Printf("Rebuilt recommendations for %d user(s)\n", n)
+	case "compute-similarity":
+		openDatabase()
+		defer db.CloseDB()
+		n, err := handlers.ComputeUserSimilarities()
+		if err != nil {
+			log.Fatalf("Failed to compute user similarities: %v\n", err)
+		}
+		log.Printf("Computed similar users for %d user(s)\n", n)
+	case "eval":
+		runEval(args[1:])
+	default:
+		log.Fatalf("Unknown command: %s\n", args[0])
+	}
+}
+
+// scores historical (served) or synthetic likes with a strategy, e.g.
+// `go run server.go eval -strategy balanced -k 10` or `go run server.go eval -synthetic 1000`
+func runEval(args []string) {
+	flags := flag.NewFlagSet("eval", flag.ExitOnError)
+	strategyName := flags.String("strategy", scoring.Current.Name, "scoring strategy to evaluate")
+	k := flags.Int("k", 10, "length of each recommendation list")
+	synthetic := flags.Int("synthetic", 0, "evaluate on this many generated users instead of the database")
+	seed := flags.Int64("seed", 1, "random seed for the synthetic dataset")
+	flags.Parse(args)
+
+	strategy, ok := scoring.Get(*strategyName)
+	if !ok {
+		log.Fatalf("Scoring strategy %q is not configured\n", *strategyName)
+	}
+
+	var dataset eval.Dataset
+	if *synthetic > 0 {
+		dataset = eval.Synthetic(*synthetic, *seed)
+	} else {
+		openDatabase()
+		defer db.CloseDB()
+		var err error
+		if dataset, err = handlers.LoadEvaluationDataset(strategy); err != nil {
+			log.Fatalf("Failed to load evaluation data: %v\n", err)
+		}
+	}
+
+	r := eval.Run(dataset, strategy, *k)
+	fmt.Printf("strategy:          %s\n", r.Strategy)
+	fmt.Printf("users:             %d (%d with likes)\n", len(dataset.Users), r.Viewers)
+	if dataset.Served != nil {
+		fmt.Printf("served lists:      %d\n", len(dataset.Served))
+	}
+	fmt.Printf("precision@%-2d:      %.4f\n", r.K, r.Precision)
+	fmt.Printf("recall@%-2d:         %.4f\n", r.K, r.Recall)
+	fmt.Printf("ndcg@%-2d:           %.4f\n", r.K, r.NDCG)
+	fmt.Printf("coverage:          %.4f\n", r.Coverage)
+	fmt.Printf("mutual match rate: %.4f\n", r.MutualMatchRate)
+	fmt.Printf("dismissal rate:    %.4f\n", r.DismissalRate)
+}
+
+// connects to the database and brings its schema up to date
+func openDatabase() {
 	db.InitDB()
+	db.ApplyMigrations()
+	if err := utils.BackfillProfileCompletedAt(); err != nil {
+		log.Fatalf("Failed to backfill profile_completed_at: %v\n", err)
+	}
+}
+
+func main() {
+	// Select the recommendation scoring strategy
+	scoring.InitScoring()
+
+	// Maintenance commands run instead of the server
+	if len(os.Args) > 1 {
+		runCommand(os.Args[1:])
+		return
+	}
+
+	// Initialize database connection and apply DB migrations
+	openDatabase()
 	defer db.CloseDB()
 
-	// Apply DB migrations
-	db.ApplyMigrations()
+	// Background jobs
+	jobs.StartUnpauseWorker(time.Minute)
+	jobs.StartRecommendationWorker(30*time.Second, 6*time.Hour)
+	// similar users only matter to the collaborative signal
+	if scoring.InUse(scoring.CollaborativeSignal{}.Name()) {
+		jobs.StartSimilarityWorker(6 * time.Hour)
+	}
+	jobs.StartExposurePruneWorker(time.Hour)
+	jobs.StartBioIndexWorker(time.Minute)
+	jobs.StartTravelWorker(time.Minute)
+	jobs.StartSentLikesPruneWorker(time.Hour)
 
 	// Serve static frontend
 	fs := http.FileServer(http.Dir("../frontend/build"))
@@ -50,18 +152,33 @@ func main() {
 
 	// Protected routes
 	http.Handle("/me", enableCORS(middleware.RequireAuth(http.HandlerFunc(handlers.MeHandler))))
+	http.Handle("/me/visibility", enableCORS(middleware.RequireAuth(http.HandlerFunc(handlers.VisibilityHandler))))
+	http.Handle("/me/travel", enableCORS(middleware.RequireAuth(http.HandlerFunc(handlers.TravelHandler))))
+	http.Handle("/me/profile/completeness", enableCORS(middleware.RequireAuth(http.HandlerFunc(handlers.ProfileCompletenessHandler))))
+	http.Handle("/me/tag-suggestions", enableCORS(middleware.RequireAuth(http.HandlerFunc(handlers.TagSuggestionsHandler))))
+	http.Handle("/me/answers", enableCORS(middleware.RequireAuth(http.HandlerFunc(handlers.AnswersHandler))))
+	http.Handle("/me/quotas", enableCORS(middleware.RequireAuth(http.HandlerFunc(handlers.QuotasHandler))))
 
 	// Serve "/profile" as a fallback to index
 	http.Handle("/profile", enableCORS(middleware.RequireCompleteProfile(http.HandlerFunc(handlers.ProfileHandler))))
 
+	// Profile attribute registry
+	http.Handle("/attributes", enableCORS(middleware.RequireAuth(http.HandlerFunc(handlers.AttributesHandler))))
+
 	// Update user’s data
 	http.Handle("/update-profile", enableCORS(middleware.RequireAuth(http.HandlerFunc(handlers.UpdateProfileHandler))))
 
+	// Compatibility questionnaire
+	http.Handle("/questions", enableCORS(middleware.RequireAuth(http.HandlerFunc(handlers.QuestionsHandler))))
+
 	// Connect/disconnect routes
 	http.Handle("/connect", enableCORS(middleware.RequireCompleteProfile(http.HandlerFunc(handlers.ConnectHandler))))
 	http.Handle("/connections/requests", enableCORS(middleware.RequireCompleteProfile(http.HandlerFunc(handlers.FetchIncomingRequestsHandler))))
+	http.Handle("/connections/inbox", enableCORS(middleware.RequireCompleteProfile(http.HandlerFunc(handlers.InboxHandler))))
 	http.Handle("/connections/respond", enableCORS(middleware.RequireCompleteProfile(http.HandlerFunc(handlers.RespondToConnectionRequestHandler))))
 	http.Handle("/connections", enableCORS(middleware.RequireCompleteProfile(http.HandlerFunc(handlers.ConnectionsHandler))))
+	http.Handle("/blocks", enableCORS(middleware.RequireCompleteProfile(http.HandlerFunc(handlers.BlocksHandler))))
+	http.Handle("/reports", enableCORS(middleware.RequireCompleteProfile(http.HandlerFunc(handlers.ReportsHandler))))
 
 	// Register specific endpoints BEFORE the generic /users/ handler.
 	http.Handle("/users/online-status", enableCORS(middleware.RequireCompleteProfile(http.HandlerFunc(handlers.OnlineStatusHandler))))
@@ -80,6 +197,10 @@ func main() {
 
 	// Recommendations
 	http.Handle("/recommendations", enableCORS(middleware.RequireCompleteProfile(http.HandlerFunc(handlers.RecommendationsHandler))))
+	http.Handle("/recommendations/", enableCORS(middleware.RequireCompleteProfile(http.HandlerFunc(handlers.RecommendationWhyHandler))))
+	http.Handle("/recommendations/feed", enableCORS(middleware.RequireCompleteProfile(http.HandlerFunc(handlers.RecommendationFeedHandler))))
+	http.Handle("/recommendations/undo", enableCORS(middleware.RequireCompleteProfile(http.HandlerFunc(handlers.UndoDismissHandler))))
+	http.Handle("/recommendations/dismissed", enableCORS(middleware.RequireCompleteProfile(http.HandlerFunc(handlers.DismissedRecommendationsHandler))))
 	http.Handle("/recommendations/dismiss", enableCORS(middleware.RequireCompleteProfile(http.HandlerFunc(handlers.DismissRecommendationHandler))))
 
 	// Admin panel route
@@ -90,6 +211,11 @@ func main() {
 	// Admin routes
 	http.Handle("/admin/load-fake-users", enableCORS(http.HandlerFunc(handlers.LoadFictitiousUsers)))
 	http.Handle("/admin/reset-database", enableCORS(http.HandlerFunc(handlers.ResetDatabase)))
+	http.Handle("/admin/experiments/report", enableCORS(http.HandlerFunc(handlers.ExperimentReportHandler)))
+	http.Handle("/admin/questions", enableCORS(http.HandlerFunc(handlers.AdminQuestionsHandler)))
+	http.Handle("/admin/reports", enableCORS(http.HandlerFunc(handlers.AdminReportsHandler)))
+	http.Handle("/admin/reports/", enableCORS(http.HandlerFunc(handlers.AdminReportHandler)))
+	http.Handle("/admin/users/", enableCORS(http.HandlerFunc(handlers.AdminUserSanctionHandler)))
 
 	log.Println("Server is running on http://localhost:3000")
 	log.Fatal(http.ListenAndServe(":8080", nil))
@@ -24,6 +24,19 @@ const hobbyOptions = [
   "Photography",
 ];
 
+const visibilityOptions = [
+  { value: "everyone", label: "Everyone who can see my profile" },
+  { value: "connections", label: "Connections only" },
+  { value: "nobody", label: "Nobody" },
+];
+
+const privacyFields = [
+  { name: "surname_visibility", label: "Who can see my last name" },
+  { name: "birthdate_visibility", label: "Who can see my exact birthdate (others see my age)" },
+  { name: "city_visibility", label: "Who can see my city" },
+  { name: "photo_visibility", label: "Who can see my photo" },
+];
+
 const interestOptions = [
   "Movies",
   "Music",
@@ -54,6 +67,10 @@ function Profile() {
     profile_picture_url: "",
     preferred_hobbies: [],
     preferred_interests: [],
+    surname_visibility: "connections",
+    birthdate_visibility: "connections",
+    city_visibility: "everyone",
+    photo_visibility: "everyone",
   });
   const [loading, setLoading] = useState(true);
   const [editing, setEditing] = useState(false);
@@ -88,6 +105,10 @@ function Profile() {
           profile_picture_url: data.profile_picture_url || "",
           preferred_hobbies: data.preferred_hobbies || [],
           preferred_interests: data.preferred_interests || [],
+          surname_visibility: data.surname_visibility || "connections",
+          birthdate_visibility: data.birthdate_visibility || "connections",
+          city_visibility: data.city_visibility || "everyone",
+          photo_visibility: data.photo_visibility || "everyone",
         });
       } catch (err) {
         console.error(err);
@@ -172,6 +193,10 @@ function Profile() {
         profile_picture_url: formData.profile_picture_url || null,
         preferred_hobbies: formData.preferred_hobbies.length > 0 ? formData.preferred_hobbies : null,
         preferred_interests: formData.preferred_interests.length > 0 ? formData.preferred_interests : null,
+        surname_visibility: formData.surname_visibility,
+        birthdate_visibility: formData.birthdate_visibility,
+        city_visibility: formData.city_visibility,
+        photo_visibility: formData.photo_visibility,
       };
       const res = await fetch("http://localhost:8080/update-profile", {
         method: "PUT",
@@ -184,7 +209,18 @@ function Profile() {
         setEditing(false);
       } else {
         const errText = await res.text();
-        alert("Error updating profile: " + errText);
+        let message = errText;
+        try {
+          const data = JSON.parse(errText);
+          if (data.fields) {
+            message = Object.entries(data.fields)
+              .map(([field, msg]) => `${field}: ${msg}`)
+              .join("\n");
+          }
+        } catch (_) {
+          // plain-text error
+        }
+        alert("Error updating profile:\n" + message);
       }
     } catch (err) {
       console.error(err);
@@ -344,6 +380,16 @@ function Profile() {
           <label>Looking for Max Age</label>
           <input type="number" name="looking_for_max_age" value={formData.looking_for_max_age} onChange={handleChange} />
         </div>
+        {privacyFields.map((field) => (
+          <div key={field.name} className="form-section">
+            <label>{field.label}</label>
+            <select name={field.name} value={formData[field.name]} onChange={handleChange}>
+              {visibilityOptions.map((opt) => (
+                <option key={opt.value} value={opt.value}>{opt.label}</option>
+              ))}
+            </select>
+          </div>
+        ))}
         <div className="form-section">
           <label>Profile Picture URL</label>
           <input type="text" name="profile_picture_url" value={formData.profile_picture_url} onChange={handleChange} />